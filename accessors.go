@@ -0,0 +1,110 @@
+package multiaddr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+// ErrWrongProtocol is returned by the typed accessors of Component when the
+// component's protocol doesn't hold the requested kind of value.
+var ErrWrongProtocol = errors.New("wrong protocol for value")
+
+// rawValue returns the component's value without copying it. The caller must
+// not retain it as a []byte.
+func (c *Component) rawValue() string {
+	return c.bytes[c.valueStartIdx:]
+}
+
+// checkCode returns an error wrapping ErrWrongProtocol if c's protocol isn't
+// one of codes.
+func (c *Component) checkCode(want string, codes ...int) error {
+	code := c.Code()
+	for _, ok := range codes {
+		if code == ok {
+			return nil
+		}
+	}
+	if c == nil || c.protocol == nil {
+		return fmt.Errorf("%w: expected %s, got an empty component", ErrWrongProtocol, want)
+	}
+	return fmt.Errorf("%w: expected %s, got /%s", ErrWrongProtocol, want, c.protocol.Name)
+}
+
+// AsAddr returns the address of an /ip4 or /ip6 component.
+func (c *Component) AsAddr() (netip.Addr, error) {
+	if err := c.checkCode("an IP address", P_IP4, P_IP6); err != nil {
+		return netip.Addr{}, err
+	}
+	v := c.rawValue()
+	switch len(v) {
+	case 4:
+		return netip.AddrFrom4([4]byte([]byte(v))), nil
+	case 16:
+		return netip.AddrFrom16([16]byte([]byte(v))), nil
+	}
+	return netip.Addr{}, fmt.Errorf("invalid IP address length %d", len(v))
+}
+
+// AsPort returns the port of a /tcp, /udp, /dccp or /sctp component.
+func (c *Component) AsPort() (uint16, error) {
+	if err := c.checkCode("a port", P_TCP, P_UDP, P_DCCP, P_SCTP); err != nil {
+		return 0, err
+	}
+	v := c.rawValue()
+	if len(v) != 2 {
+		return 0, fmt.Errorf("invalid port length %d", len(v))
+	}
+	return uint16(v[0])<<8 | uint16(v[1]), nil
+}
+
+// AsPrefixLen returns the prefix length of an /ipcidr component.
+func (c *Component) AsPrefixLen() (int, error) {
+	if err := c.checkCode("a prefix length", P_IPCIDR); err != nil {
+		return 0, err
+	}
+	v := c.rawValue()
+	if len(v) != 1 {
+		return 0, fmt.Errorf("invalid prefix length size %d", len(v))
+	}
+	return int(v[0]), nil
+}
+
+// AsMultihash returns the multihash of a /p2p or /certhash component.
+func (c *Component) AsMultihash() (mh.Multihash, error) {
+	if err := c.checkCode("a multihash", P_P2P, P_CERTHASH); err != nil {
+		return nil, err
+	}
+	return mh.Cast([]byte(c.rawValue()))
+}
+
+// AsPeerIDBytes returns the binary form of the peer ID of a /p2p component.
+func (c *Component) AsPeerIDBytes() ([]byte, error) {
+	if err := c.checkCode("a peer ID", P_P2P); err != nil {
+		return nil, err
+	}
+	return []byte(c.rawValue()), nil
+}
+
+// AsCertHash returns the decoded multihash of a /certhash component.
+func (c *Component) AsCertHash() (*mh.DecodedMultihash, error) {
+	if err := c.checkCode("a certificate hash", P_CERTHASH); err != nil {
+		return nil, err
+	}
+	return mh.Decode([]byte(c.rawValue()))
+}
+
+// AsMemoryID returns the ID of a /memory component.
+func (c *Component) AsMemoryID() (uint64, error) {
+	if err := c.checkCode("a memory ID", P_MEMORY); err != nil {
+		return 0, err
+	}
+	v := c.rawValue()
+	if len(v) != 8 {
+		return 0, fmt.Errorf("invalid memory ID length %d", len(v))
+	}
+	return binary.BigEndian.Uint64([]byte(v)), nil
+}
@@ -0,0 +1,84 @@
+package multiaddr
+
+import (
+	"net/netip"
+	"testing"
+
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentAccessors(t *testing.T) {
+	m := StringCast("/ip6/::1/ipcidr/64/udp/4001/quic-v1/webtransport/certhash/uEiDDq4_xNyDorZBH3TlGazyJdOWSwvo4PUo5YHFMrvDE8g/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC")
+
+	addr, err := m[0].AsAddr()
+	require.NoError(t, err)
+	require.Equal(t, netip.MustParseAddr("::1"), addr)
+
+	bits, err := m[1].AsPrefixLen()
+	require.NoError(t, err)
+	require.Equal(t, 64, bits)
+
+	port, err := m[2].AsPort()
+	require.NoError(t, err)
+	require.Equal(t, uint16(4001), port)
+
+	ch, err := m[5].AsCertHash()
+	require.NoError(t, err)
+	require.Equal(t, uint64(mh.SHA2_256), ch.Code)
+
+	hash, err := m[5].AsMultihash()
+	require.NoError(t, err)
+	require.Equal(t, m[5].RawValue(), []byte(hash))
+
+	id, err := m[6].AsPeerIDBytes()
+	require.NoError(t, err)
+	require.Equal(t, "QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC", mh.Multihash(id).B58String())
+	hash, err = m[6].AsMultihash()
+	require.NoError(t, err)
+	require.Equal(t, id, []byte(hash))
+
+	mem := StringCast("/memory/1234")
+	memID, err := mem[0].AsMemoryID()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1234), memID)
+
+	ip4 := StringCast("/ip4/1.2.3.4")
+	addr, err = ip4[0].AsAddr()
+	require.NoError(t, err)
+	require.True(t, addr.Is4())
+}
+
+func TestComponentAccessorsWrongProtocol(t *testing.T) {
+	c := StringCast("/ip4/1.2.3.4")[0]
+	_, err := c.AsPort()
+	require.ErrorIs(t, err, ErrWrongProtocol)
+	_, err = c.AsPrefixLen()
+	require.ErrorIs(t, err, ErrWrongProtocol)
+	_, err = c.AsMultihash()
+	require.ErrorIs(t, err, ErrWrongProtocol)
+	_, err = c.AsPeerIDBytes()
+	require.ErrorIs(t, err, ErrWrongProtocol)
+	_, err = c.AsCertHash()
+	require.ErrorIs(t, err, ErrWrongProtocol)
+	_, err = c.AsMemoryID()
+	require.ErrorIs(t, err, ErrWrongProtocol)
+
+	tcp := StringCast("/tcp/1")[0]
+	_, err = tcp.AsAddr()
+	require.ErrorIs(t, err, ErrWrongProtocol)
+
+	var nilComponent *Component
+	_, err = nilComponent.AsAddr()
+	require.ErrorIs(t, err, ErrWrongProtocol)
+}
+
+func TestComponentAccessorsDoNotAllocate(t *testing.T) {
+	m := StringCast("/ip6/::1/tcp/1/memory/5")
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = m[0].AsAddr()
+		_, _ = m[1].AsPort()
+		_, _ = m[2].AsMemoryID()
+	})
+	require.Zero(t, allocs)
+}
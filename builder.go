@@ -0,0 +1,251 @@
+package multiaddr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+// ErrInvalidComposition is returned by a Builder or a Rewriter when a component
+// can't follow the previous one, e.g., /quic-v1 after /tcp.
+var ErrInvalidComposition = errors.New("invalid multiaddr composition")
+
+// NewIPComponent returns an /ip4 component for IPv4 addresses and an /ip6
+// component for IPv6 addresses, including IPv4-mapped ones. Addresses with a
+// zone are rejected, use Builder.IP to add the /ip6zone component as well.
+func NewIPComponent(addr netip.Addr) (*Component, error) {
+	switch {
+	case !addr.IsValid():
+		return nil, fmt.Errorf("invalid IP address")
+	case addr.Zone() != "":
+		return nil, fmt.Errorf("IP address %s has a zone", addr)
+	case addr.Is4():
+		b := addr.As4()
+		return newComponentWithCode(P_IP4, b[:])
+	default:
+		b := addr.As16()
+		return newComponentWithCode(P_IP6, b[:])
+	}
+}
+
+// NewPortComponent returns a component for a port-based protocol: /tcp, /udp,
+// /dccp or /sctp.
+func NewPortComponent(code int, port uint16) (*Component, error) {
+	switch code {
+	case P_TCP, P_UDP, P_DCCP, P_SCTP:
+	default:
+		return nil, fmt.Errorf("protocol %d doesn't take a port", code)
+	}
+	return newComponentWithCode(code, binary.BigEndian.AppendUint16(nil, port))
+}
+
+// NewCertHashComponent returns a /certhash component for the given multihash.
+func NewCertHashComponent(hash mh.Multihash) (*Component, error) {
+	return newComponentWithCode(P_CERTHASH, hash)
+}
+
+// NewP2PComponent returns a /p2p component for the binary form of a peer ID.
+func NewP2PComponent(peerID []byte) (*Component, error) {
+	return newComponentWithCode(P_P2P, peerID)
+}
+
+// Builder constructs a multiaddr one component at a time, encoding values
+// directly to bytes rather than going through their string representation.
+// It checks that each component can follow the previous one.
+//
+// The first error is recorded and returned by Multiaddr. Calls after an error
+// are no-ops, so the methods can be chained:
+//
+//	m, err := multiaddr.Build().IP(addr).UDP(4001).QUICv1().P2P(id).Multiaddr()
+type Builder struct {
+	m   Multiaddr
+	err error
+}
+
+// Build returns an empty Builder.
+func Build() *Builder {
+	return &Builder{}
+}
+
+// Multiaddr returns the multiaddr built so far, or the first error
+// encountered.
+func (b *Builder) Multiaddr() (Multiaddr, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.m.copy(), nil
+}
+
+// Err returns the first error encountered, if any.
+func (b *Builder) Err() error {
+	return b.err
+}
+
+// Component appends c, checking that it can follow the previous component.
+func (b *Builder) Component(c Component) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if err := b.checkComposition(&c); err != nil {
+		b.err = err
+		return b
+	}
+	b.m = append(b.m, c)
+	return b
+}
+
+// add appends the component returned by newComponent, or records its error.
+func (b *Builder) add(c *Component, err error) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.Component(*c)
+}
+
+func (b *Builder) addCode(code int, value []byte) *Builder {
+	if b.err != nil {
+		return b
+	}
+	return b.add(newComponentWithCode(code, value))
+}
+
+// IP appends an /ip4 or /ip6 component. The zone of IPv6 addresses is added
+// as an /ip6zone component.
+func (b *Builder) IP(addr netip.Addr) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if zone := addr.Zone(); zone != "" {
+		b.addCode(P_IP6ZONE, []byte(zone))
+		addr = addr.WithZone("")
+	}
+	return b.add(NewIPComponent(addr))
+}
+
+// DNS appends a /dns component.
+func (b *Builder) DNS(name string) *Builder { return b.addCode(P_DNS, []byte(name)) }
+
+// DNS4 appends a /dns4 component.
+func (b *Builder) DNS4(name string) *Builder { return b.addCode(P_DNS4, []byte(name)) }
+
+// DNS6 appends a /dns6 component.
+func (b *Builder) DNS6(name string) *Builder { return b.addCode(P_DNS6, []byte(name)) }
+
+// DNSAddr appends a /dnsaddr component.
+func (b *Builder) DNSAddr(name string) *Builder { return b.addCode(P_DNSADDR, []byte(name)) }
+
+// TCP appends a /tcp component.
+func (b *Builder) TCP(port uint16) *Builder { return b.add(NewPortComponent(P_TCP, port)) }
+
+// UDP appends a /udp component.
+func (b *Builder) UDP(port uint16) *Builder { return b.add(NewPortComponent(P_UDP, port)) }
+
+// QUICv1 appends a /quic-v1 component.
+func (b *Builder) QUICv1() *Builder { return b.addCode(P_QUIC_V1, nil) }
+
+// WebTransport appends a /webtransport component.
+func (b *Builder) WebTransport() *Builder { return b.addCode(P_WEBTRANSPORT, nil) }
+
+// WebRTCDirect appends a /webrtc-direct component.
+func (b *Builder) WebRTCDirect() *Builder { return b.addCode(P_WEBRTC_DIRECT, nil) }
+
+// CertHash appends a /certhash component.
+func (b *Builder) CertHash(hash mh.Multihash) *Builder { return b.add(NewCertHashComponent(hash)) }
+
+// TLS appends a /tls component.
+func (b *Builder) TLS() *Builder { return b.addCode(P_TLS, nil) }
+
+// SNI appends a /sni component.
+func (b *Builder) SNI(name string) *Builder { return b.addCode(P_SNI, []byte(name)) }
+
+// Noise appends a /noise component.
+func (b *Builder) Noise() *Builder { return b.addCode(P_NOISE, nil) }
+
+// WS appends a /ws component.
+func (b *Builder) WS() *Builder { return b.addCode(P_WS, nil) }
+
+// HTTP appends an /http component.
+func (b *Builder) HTTP() *Builder { return b.addCode(P_HTTP, nil) }
+
+// HTTPPath appends an /http-path component. path is not escaped.
+func (b *Builder) HTTPPath(path string) *Builder { return b.addCode(P_HTTP_PATH, []byte(path)) }
+
+// P2P appends a /p2p component with the binary form of a peer ID.
+func (b *Builder) P2P(peerID []byte) *Builder { return b.add(NewP2PComponent(peerID)) }
+
+// Circuit appends a /p2p-circuit component.
+func (b *Builder) Circuit() *Builder { return b.addCode(P_CIRCUIT, nil) }
+
+// layerStart stands for the start of a multiaddr in precedingLayers.
+const layerStart Layer = -1
+
+// precedingLayers lists the layers of the protocols that may precede a
+// protocol, by the protocol's layer. Layers that are not in the map, and
+// protocols of an unknown layer, may follow anything.
+var precedingLayers = map[Layer][]Layer{
+	LayerNetwork:     {layerStart, LayerPeer},
+	LayerTransport:   {LayerNetwork, LayerTransport, LayerSecurity, LayerPeer},
+	LayerSecurity:    {LayerTransport, LayerSecurity},
+	LayerMuxer:       {LayerTransport, LayerSecurity},
+	LayerApplication: {LayerTransport, LayerSecurity, LayerApplication},
+}
+
+// precedingProtocols lists the only protocols that may precede a protocol,
+// for protocols that are narrower than their layer. A zero code means the
+// protocol may start a multiaddr.
+var precedingProtocols = map[int][]int{
+	P_IP6:           {0, P_IP6ZONE, P_CIRCUIT},
+	P_IPCIDR:        {P_IP4, P_IP6},
+	P_MEMORY:        {0},
+	P_QUIC:          {P_UDP},
+	P_QUIC_V1:       {P_UDP},
+	P_WEBTRANSPORT:  {P_QUIC_V1, P_SNI},
+	P_WEBRTC_DIRECT: {P_UDP},
+	P_WEBRTC:        {0, P_CIRCUIT},
+	P_CERTHASH:      {P_WEBTRANSPORT, P_WEBRTC_DIRECT, P_CERTHASH},
+	P_SNI:           {P_TLS, P_QUIC, P_QUIC_V1},
+	P_HTTP_PATH:     {P_HTTP, P_HTTPS, P_WS, P_WSS},
+}
+
+func (b *Builder) checkComposition(c *Component) error {
+	var prev Protocol
+	if len(b.m) > 0 {
+		prev = b.m[len(b.m)-1].Protocol()
+	}
+	return checkComposition(prev, c.Protocol())
+}
+
+// checkComposition checks that p may follow prev. A zero prev stands for the
+// start of a multiaddr.
+func checkComposition(prev, p Protocol) error {
+	if canFollow(prev, p) {
+		return nil
+	}
+	if prev.Code == 0 {
+		return fmt.Errorf("%w: /%s can't start a multiaddr", ErrInvalidComposition, p.Name)
+	}
+	return fmt.Errorf("%w: /%s can't follow /%s", ErrInvalidComposition, p.Name, prev.Name)
+}
+
+func canFollow(prev, p Protocol) bool {
+	if allowed, ok := precedingProtocols[p.Code]; ok {
+		return slices.Contains(allowed, prev.Code)
+	}
+	allowed, ok := precedingLayers[p.Layer]
+	if !ok {
+		return true
+	}
+	prevLayer := layerStart
+	if prev.Code != 0 {
+		prevLayer = prev.Layer
+	}
+	return prevLayer == LayerUnknown || slices.Contains(allowed, prevLayer)
+}
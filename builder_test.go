@@ -0,0 +1,181 @@
+package multiaddr
+
+import (
+	"net/netip"
+	"testing"
+
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	peerID, err := mh.FromB58String("QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC")
+	require.NoError(t, err)
+	hash, err := mh.Sum([]byte("cert"), mh.SHA2_256, -1)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		b    *Builder
+		want string
+	}{
+		{
+			Build().IP(netip.MustParseAddr("1.2.3.4")).TCP(4001).P2P(peerID),
+			"/ip4/1.2.3.4/tcp/4001/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		},
+		{
+			Build().IP(netip.MustParseAddr("::1")).UDP(4001).QUICv1().WebTransport().CertHash(hash),
+			"/ip6/::1/udp/4001/quic-v1/webtransport/certhash/" + mustCertHashString(t, hash),
+		},
+		{
+			Build().IP(netip.MustParseAddr("fe80::1%eth0")).UDP(1).WebRTCDirect(),
+			"/ip6zone/eth0/ip6/fe80::1/udp/1/webrtc-direct",
+		},
+		{
+			Build().DNS("example.com").TCP(443).TLS().SNI("example.com").HTTP().HTTPPath("a/b"),
+			"/dns/example.com/tcp/443/tls/sni/example.com/http/http-path/a%2Fb",
+		},
+		{
+			Build().DNS4("example.com").TCP(443).TLS().WS(),
+			"/dns4/example.com/tcp/443/tls/ws",
+		},
+		{
+			Build().IP(netip.MustParseAddr("1.2.3.4")).TCP(1).P2P(peerID).Circuit().P2P(peerID),
+			"/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		},
+		{
+			Build().DNSAddr("bootstrap.libp2p.io").P2P(peerID),
+			"/dnsaddr/bootstrap.libp2p.io/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		},
+		{
+			Build().IP(netip.MustParseAddr("1.2.3.4")).UDP(443).QUICv1().HTTP(),
+			"/ip4/1.2.3.4/udp/443/quic-v1/http",
+		},
+		{
+			Build().IP(netip.MustParseAddr("1.2.3.4")).TCP(80).HTTP().HTTPPath("a"),
+			"/ip4/1.2.3.4/tcp/80/http/http-path/a",
+		},
+		{
+			Build().IP(netip.MustParseAddr("1.2.3.4")).TCP(1).WS().Noise(),
+			"/ip4/1.2.3.4/tcp/1/ws/noise",
+		},
+		{
+			Build().IP(netip.MustParseAddr("1.2.3.4")).UDP(1).QUICv1().Noise(),
+			"/ip4/1.2.3.4/udp/1/quic-v1/noise",
+		},
+		{
+			Build().IP(netip.MustParseAddr("1.2.3.4")).TCP(1).P2P(peerID).Circuit().Component(StringCast("/webrtc")[0]),
+			"/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit/webrtc",
+		},
+	} {
+		m, err := tc.b.Multiaddr()
+		require.NoError(t, err)
+		require.Equal(t, tc.want, m.String())
+		require.True(t, m.Equal(StringCast(tc.want)))
+	}
+}
+
+func TestBuilderAcceptsLibp2pAddrs(t *testing.T) {
+	for _, s := range []string{
+		"/ip4/1.2.3.4/tcp/4001/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		"/ip4/1.2.3.4/udp/4001/quic-v1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		"/ip4/1.2.3.4/udp/4001/quic-v1/webtransport/certhash/b2uaraocy6yrdblb4sfptaddgimjmmpy/certhash/zQmbWTwYGcmdyK9CYfNBcfs9nhZs17a6FQ4Y8oea278xx41",
+		"/ip4/1.2.3.4/udp/443/quic-v1/sni/example.com/webtransport",
+		"/ip4/1.2.3.4/udp/443/quic/sni/example.com",
+		"/ip6/::1/udp/4001/webrtc-direct/certhash/uEiDDq4_xNyDorZBH3TlGazyJdOWSwvo4PUo5YHFMrvDE8g/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		"/dns4/example.com/tcp/443/tls/sni/example.com/ws/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		"/dns/example.com/tcp/443/wss",
+		"/dnsaddr/bootstrap.libp2p.io/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		"/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit/webrtc/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		"/webrtc",
+		"/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		"/unix/tmp/p2p.sock",
+		"/memory/1234",
+	} {
+		t.Run(s, func(t *testing.T) {
+			want := StringCast(s)
+			b := Build()
+			for _, c := range want {
+				b.Component(c)
+			}
+			m, err := b.Multiaddr()
+			require.NoError(t, err)
+			require.True(t, m.Equal(want))
+		})
+	}
+}
+
+func mustCertHashString(t *testing.T, hash mh.Multihash) string {
+	c, err := NewCertHashComponent(hash)
+	require.NoError(t, err)
+	return c.Value()
+}
+
+func TestBuilderErrors(t *testing.T) {
+	ip := netip.MustParseAddr("1.2.3.4")
+	for _, tc := range []struct {
+		name string
+		b    *Builder
+	}{
+		{"quic after tcp", Build().IP(ip).TCP(1).QUICv1()},
+		{"tcp first", Build().TCP(1)},
+		{"sni without tls", Build().IP(ip).TCP(1).SNI("example.com")},
+		{"two hosts", Build().IP(ip).DNS("example.com")},
+		{"webtransport after udp", Build().IP(ip).UDP(1).WebTransport()},
+		{"http first", Build().HTTP()},
+		{"http after a host", Build().IP(ip).HTTP()},
+		{"noise after a host", Build().IP(ip).Noise()},
+		{"http-path after tls", Build().IP(ip).TCP(1).TLS().HTTPPath("a")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.b.Multiaddr()
+			require.ErrorIs(t, err, ErrInvalidComposition)
+		})
+	}
+
+	// The first error sticks.
+	b := Build().IP(netip.Addr{}).TCP(1)
+	require.Error(t, b.Err())
+	require.NotErrorIs(t, b.Err(), ErrInvalidComposition)
+
+	_, err := Build().DNS("a/b").Multiaddr()
+	require.ErrorIs(t, err, ErrInvalidValue)
+	_, err = Build().P2P([]byte("not a multihash")).Multiaddr()
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestBuilderReuse(t *testing.T) {
+	b := Build().IP(netip.MustParseAddr("1.2.3.4")).UDP(1)
+	quic, err := b.QUICv1().Multiaddr()
+	require.NoError(t, err)
+	quic[0] = quic[1]
+	m, err := b.Multiaddr()
+	require.NoError(t, err)
+	require.Equal(t, "/ip4/1.2.3.4/udp/1/quic-v1", m.String())
+}
+
+func TestTypedComponents(t *testing.T) {
+	c, err := NewIPComponent(netip.MustParseAddr("1.2.3.4"))
+	require.NoError(t, err)
+	require.Equal(t, "/ip4/1.2.3.4", c.String())
+
+	c, err = NewIPComponent(netip.MustParseAddr("::ffff:1.2.3.4"))
+	require.NoError(t, err)
+	require.Equal(t, P_IP6, c.Code())
+
+	_, err = NewIPComponent(netip.MustParseAddr("fe80::1%eth0"))
+	require.Error(t, err)
+	_, err = NewIPComponent(netip.Addr{})
+	require.Error(t, err)
+
+	for _, code := range []int{P_TCP, P_UDP, P_DCCP, P_SCTP} {
+		c, err := NewPortComponent(code, 65535)
+		require.NoError(t, err)
+		require.Equal(t, "65535", c.Value())
+		require.Equal(t, code, c.Code())
+	}
+	_, err = NewPortComponent(P_IP4, 1)
+	require.Error(t, err)
+
+	_, err = NewCertHashComponent(mh.Multihash{0x12})
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
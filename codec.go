@@ -9,94 +9,139 @@ import (
 )
 
 func stringToBytes(s string) ([]byte, error) {
+	return defaultParser.stringToBytes(s)
+}
+
+func (pr *Parser) stringToBytes(s string) ([]byte, error) {
+	input := s
 	// consume trailing slashes
 	s = strings.TrimRight(s, "/")
 
-	var b bytes.Buffer
-	sp := strings.Split(s, "/")
-
-	if sp[0] != "" {
-		return nil, fmt.Errorf("failed to parse multiaddr %q: must begin with /", s)
+	if s == "" {
+		return nil, &ParseError{Input: input, Component: -1, Err: ErrEmptyMultiaddr}
+	}
+	if s[0] != '/' {
+		return nil, &ParseError{Input: input, Component: -1, Err: ErrMissingLeadingSlash}
+	}
+	if err := pr.checkStringLength(len(s)); err != nil {
+		return nil, &ParseError{Input: input, Component: -1, Err: err}
 	}
 
-	// consume first empty elem
-	sp = sp[1:]
-
-	if len(sp) == 0 {
-		return nil, fmt.Errorf("failed to parse multiaddr %q: empty multiaddr", s)
+	var b bytes.Buffer
+	// rest holds the segments that haven't been read, and more is false once
+	// they all were. Segments are read one at a time, so that the limits stop
+	// the walk before the rest of the input is looked at.
+	rest, more := s[1:], true
+	nextSegment := func() string {
+		var seg string
+		seg, rest, more = strings.Cut(rest, "/")
+		return seg
 	}
 
-	for len(sp) > 0 {
-		name := sp[0]
-		p := ProtocolWithName(name)
-		if p.Code == 0 {
-			return nil, fmt.Errorf("failed to parse multiaddr %q: unknown protocol %s", s, sp[0])
+	// offset is the index in s of the next segment.
+	offset := 1
+	for i := 0; more; i++ {
+		if err := pr.checkComponentCount(i + 1); err != nil {
+			return nil, &ParseError{Input: input, Offset: offset, Component: i, Err: err}
+		}
+		name := nextSegment()
+		p := pr.registry().lookupName(name)
+		if p == nil {
+			return nil, &ParseError{
+				Input:     input,
+				Offset:    offset,
+				Component: i,
+				Protocol:  name,
+				Err:       fmt.Errorf("%w %q", ErrUnknownProtocol, name),
+			}
 		}
+		if err := pr.checkProtocol(p); err != nil {
+			return nil, &ParseError{Input: input, Offset: offset, Component: i, Protocol: p.Name, Err: err}
+		}
+		componentOffset := offset
 		_, _ = b.Write(p.VCode)
-		sp = sp[1:]
+		offset += len(name) + 1
 
 		if p.Size == 0 { // no length.
+			if err := pr.checkSize(b.Len()); err != nil {
+				return nil, &ParseError{Input: input, Offset: componentOffset, Component: i, Protocol: p.Name, Err: err}
+			}
 			continue
 		}
 
-		if len(sp) < 1 {
-			return nil, fmt.Errorf("failed to parse multiaddr %q: unexpected end of multiaddr", s)
+		if !more {
+			return nil, &ParseError{
+				Input:     input,
+				Offset:    len(s),
+				Component: i,
+				Protocol:  p.Name,
+				Err:       ErrTruncated,
+			}
 		}
 
+		var value string
 		if p.Path {
 			// it's a path protocol (terminal).
 			// consume the rest of the address as the next component.
-			sp = []string{"/" + strings.Join(sp, "/")}
+			// The value starts at the slash preceding it.
+			offset--
+			value = s[offset:]
+			rest, more = "", false
+		} else {
+			value = nextSegment()
 		}
 
-		a, err := p.Transcoder.StringToBytes(sp[0])
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse multiaddr %q: invalid value %q for protocol %s: %s", s, sp[0], p.Name, err)
+		a, err := p.Transcoder.StringToBytes(value)
+		if err == nil {
+			err = p.Transcoder.ValidateBytes(a)
 		}
-		err = p.Transcoder.ValidateBytes(a)
 		if err != nil {
-			return nil, fmt.Errorf("failed to validate multiaddr %q: invalid value %q for protocol %s: %w", s, sp[0], p.Name, err)
+			return nil, &ParseError{
+				Input:     input,
+				Offset:    offset,
+				Component: i,
+				Protocol:  p.Name,
+				Value:     value,
+				Err:       invalidValueError(value, err),
+			}
+		}
+		if err := pr.checkValueLength(p, len(a)); err != nil {
+			return nil, &ParseError{Input: input, Offset: offset, Component: i, Protocol: p.Name, Value: value, Err: err}
 		}
 		if p.Size < 0 { // varint size.
 			_, _ = b.Write(varint.ToUvarint(uint64(len(a))))
 		}
 		b.Write(a)
-		sp = sp[1:]
+		if err := pr.checkSize(b.Len()); err != nil {
+			return nil, &ParseError{Input: input, Offset: componentOffset, Component: i, Protocol: p.Name, Err: err}
+		}
+		offset += len(value) + 1
 	}
 
 	return b.Bytes(), nil
 }
 
-func readComponent(b []byte) (int, *Component, error) {
+// readComponentHeader decodes the protocol code and, for variable sized
+// protocols, the length prefix of the component at the start of b. It returns
+// the component's protocol, the index of the first byte of its value and the
+// total length of the component. The value itself is not validated.
+//
+// Errors are returned as a *ParseError positioned relative to b.
+func readComponentHeader(r *Registry, b []byte) (*Protocol, int, int, error) {
 	var offset int
 	code, n, err := ReadVarintCode(b)
 	if err != nil {
-		return 0, nil, err
+		return nil, 0, 0, &ParseError{Err: varintError(err)}
 	}
 	offset += n
 
-	p := ProtocolWithCode(code)
-	if p.Code == 0 {
-		return 0, nil, fmt.Errorf("no protocol with code %d", code)
-	}
-	pPtr := protocolPtrByCode[code]
-	if pPtr == nil {
-		return 0, nil, fmt.Errorf("no protocol with code %d", code)
+	p := r.lookupCode(code)
+	if p == nil {
+		return nil, 0, 0, &ParseError{Err: fmt.Errorf("%w with code %d", ErrUnknownProtocol, code)}
 	}
 
 	if p.Size == 0 {
-		c := &Component{
-			bytes:         string(b[:offset]),
-			valueStartIdx: offset,
-			protocol:      pPtr,
-		}
-
-		err := validateComponent(c)
-		if err != nil {
-			return 0, nil, err
-		}
-
-		return offset, c, nil
+		return p, offset, offset, nil
 	}
 
 	var size int
@@ -105,7 +150,7 @@ func readComponent(b []byte) (int, *Component, error) {
 		var n int
 		size, n, err = ReadVarintCode(b[offset:])
 		if err != nil {
-			return 0, nil, err
+			return nil, 0, 0, &ParseError{Offset: offset, Protocol: p.Name, Err: varintError(err)}
 		}
 		offset += n
 	} else {
@@ -113,43 +158,89 @@ func readComponent(b []byte) (int, *Component, error) {
 		size = p.Size / 8
 	}
 
-	if len(b[offset:]) < size || size <= 0 {
-		return 0, nil, fmt.Errorf("invalid value for size %d", len(b[offset:]))
+	if size <= 0 {
+		return nil, 0, 0, &ParseError{
+			Offset:   offset,
+			Protocol: p.Name,
+			Err:      fmt.Errorf("%w: empty value", ErrInvalidValue),
+		}
+	}
+	if len(b[offset:]) < size {
+		return nil, 0, 0, &ParseError{
+			Offset:   offset,
+			Protocol: p.Name,
+			Err:      fmt.Errorf("%w: value needs %d bytes, %d left", ErrTruncated, size, len(b[offset:])),
+		}
+	}
+
+	return p, offset, offset + size, nil
+}
+
+func readComponent(b []byte) (int, *Component, error) {
+	return defaultParser.readComponent(b)
+}
+
+func (pr *Parser) readComponent(b []byte) (int, *Component, error) {
+	p, valueStartIdx, end, err := readComponentHeader(pr.registry(), b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := pr.checkProtocol(p); err != nil {
+		return 0, nil, &ParseError{Protocol: p.Name, Err: err}
+	}
+	if err := pr.checkValueLength(p, end-valueStartIdx); err != nil {
+		return 0, nil, &ParseError{Offset: valueStartIdx, Protocol: p.Name, Err: err}
 	}
 
 	c := &Component{
-		bytes:         string(b[:offset+size]),
-		protocol:      pPtr,
-		valueStartIdx: offset,
+		bytes:         string(b[:end]),
+		protocol:      p,
+		valueStartIdx: valueStartIdx,
 	}
 	err = validateComponent(c)
 	if err != nil {
-		return 0, nil, err
+		return 0, nil, &ParseError{
+			Offset:   valueStartIdx,
+			Protocol: p.Name,
+			Err:      invalidValueError("", err),
+		}
 	}
 
-	return offset + size, c, err
+	return end, c, nil
 }
 
-func readMultiaddr(b []byte) (int, Multiaddr, error) {
+func (pr *Parser) readMultiaddr(b []byte) (int, Multiaddr, error) {
 	if len(b) == 0 {
-		return 0, nil, fmt.Errorf("empty multiaddr")
+		return 0, nil, &ParseError{Component: -1, Err: ErrEmptyMultiaddr}
+	}
+	if err := pr.checkSize(len(b)); err != nil {
+		return 0, nil, &ParseError{Offset: pr.opts.MaxBytes, Component: -1, Err: err}
 	}
 
 	var res Multiaddr
 	bytesRead := 0
 	sawPathComponent := false
 	for len(b) > 0 {
-		n, c, err := readComponent(b)
+		if err := pr.checkComponentCount(len(res) + 1); err != nil {
+			return 0, nil, &ParseError{Offset: bytesRead, Component: len(res), Err: err}
+		}
+		n, c, err := pr.readComponent(b)
 		if err != nil {
-			return 0, nil, err
+			return 0, nil, withPosition(err, bytesRead, len(res))
 		}
-		b = b[n:]
-		bytesRead += n
 
 		if sawPathComponent {
 			// It is an error to have another component after a path component.
-			return bytesRead, nil, fmt.Errorf("unexpected component after path component")
+			return bytesRead, nil, &ParseError{
+				Offset:    bytesRead,
+				Component: len(res),
+				Protocol:  c.protocol.Name,
+				Err:       fmt.Errorf("%w: unexpected component after path component", ErrTrailingData),
+			}
 		}
+		b = b[n:]
+		bytesRead += n
+
 		sawPathComponent = c.protocol.Path
 		res = append(res, *c)
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -220,13 +221,16 @@ func (c *Component) writeTo(b *strings.Builder) {
 	if c.protocol == nil {
 		return
 	}
+	writeComponentTo(b, c.protocol, c.Value())
+}
+
+func writeComponentTo(b *strings.Builder, p *Protocol, value string) {
 	b.WriteByte('/')
-	b.WriteString(c.protocol.Name)
-	value := c.Value()
+	b.WriteString(p.Name)
 	if len(value) == 0 {
 		return
 	}
-	if !(c.protocol.Path && value[0] == '/') {
+	if !(p.Path && value[0] == '/') {
 		b.WriteByte('/')
 	}
 	b.WriteString(value)
@@ -234,28 +238,56 @@ func (c *Component) writeTo(b *strings.Builder) {
 
 // NewComponent constructs a new multiaddr component
 func NewComponent(protocol, value string) (*Component, error) {
-	p := ProtocolWithName(protocol)
-	if p.Code == 0 {
-		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
+	return newComponentFromString(defaultRegistry, protocol, value)
+}
+
+func newComponentFromString(r *Registry, protocol, value string) (*Component, error) {
+	input := "/" + protocol
+	if value != "" {
+		input += "/" + value
+	}
+	p := r.lookupName(protocol)
+	if p == nil {
+		return nil, &ParseError{
+			Input:    input,
+			Offset:   1,
+			Protocol: protocol,
+			Err:      fmt.Errorf("%w %q", ErrUnknownProtocol, protocol),
+		}
+	}
+	valueErr := func(err error) error {
+		return &ParseError{
+			Input:    input,
+			Offset:   len(protocol) + 2,
+			Protocol: p.Name,
+			Value:    value,
+			Err:      invalidValueError(value, err),
+		}
 	}
+	var bts []byte
 	if p.Transcoder != nil {
-		bts, err := p.Transcoder.StringToBytes(value)
+		var err error
+		bts, err = p.Transcoder.StringToBytes(value)
 		if err != nil {
-			return nil, err
+			return nil, valueErr(err)
 		}
-		return newComponent(p, bts)
 	} else if value != "" {
-		return nil, fmt.Errorf("protocol %s doesn't take a value", p.Name)
+		return nil, valueErr(fmt.Errorf("protocol %s doesn't take a value", p.Name))
 	}
-	return newComponent(p, nil)
-}
-
-func newComponent(protocol Protocol, bvalue []byte) (*Component, error) {
-	protocolPtr := protocolPtrByCode[protocol.Code]
-	if protocolPtr == nil {
-		protocolPtr = &protocol
+	c, err := newComponent(p, bts)
+	if err != nil {
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			pe.Input = input
+			pe.Offset = len(protocol) + 2
+			pe.Value = value
+		}
+		return nil, err
 	}
+	return c, nil
+}
 
+func newComponent(protocol *Protocol, bvalue []byte) (*Component, error) {
 	size := len(bvalue)
 	size += len(protocol.VCode)
 	if protocol.Size < 0 {
@@ -276,13 +308,17 @@ func newComponent(protocol Protocol, bvalue []byte) (*Component, error) {
 
 	c := &Component{
 		bytes:         string(maddr),
-		protocol:      protocolPtr,
+		protocol:      protocol,
 		valueStartIdx: offset,
 	}
 
 	err := validateComponent(c)
 	if err != nil {
-		return nil, err
+		return nil, &ParseError{
+			Offset:   offset,
+			Protocol: protocol.Name,
+			Err:      invalidValueError("", err),
+		}
 	}
 	return c, nil
 }
@@ -334,16 +370,18 @@ func validateComponent(c *Component) error {
 		}
 	}
 
-	_, err := c.valueAndErr()
-	if err != nil {
-		return err
+	return validateComponentValue(c.protocol, []byte(c.bytes[c.valueStartIdx:]))
+}
 
+// validateComponentValue checks that value can be decoded and is valid for the
+// protocol p.
+func validateComponentValue(p *Protocol, value []byte) error {
+	if p.Transcoder == nil {
+		return nil
 	}
-	if c.protocol.Transcoder != nil {
-		err = c.protocol.Transcoder.ValidateBytes([]byte(c.bytes[c.valueStartIdx:]))
-		if err != nil {
-			return err
-		}
+	_, err := p.Transcoder.BytesToString(value)
+	if err != nil {
+		return err
 	}
-	return nil
+	return p.Transcoder.ValidateBytes(value)
 }
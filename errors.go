@@ -0,0 +1,113 @@
+package multiaddr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/multiformats/go-multiaddr/x/meg"
+	"github.com/multiformats/go-varint"
+)
+
+// Causes of a ParseError. Use errors.Is to test for them.
+var (
+	ErrEmptyMultiaddr      = errors.New("empty multiaddr")
+	ErrMissingLeadingSlash = errors.New("must begin with /")
+	ErrUnknownProtocol     = errors.New("unknown protocol")
+	ErrInvalidValue        = errors.New("invalid value")
+	ErrInvalidVarint       = errors.New("invalid varint")
+	ErrTruncated           = errors.New("unexpected end of multiaddr")
+	ErrTrailingData        = errors.New("unexpected trailing data")
+	ErrProtocolNotAllowed  = errors.New("protocol not allowed")
+	ErrLimitExceeded       = errors.New("limit exceeded")
+)
+
+// ParseError is returned when a multiaddr fails to parse, either from its
+// string or from its binary representation. It records where in the input the
+// problem was found so that tools can point at the offending component.
+type ParseError struct {
+	// Input is the string being parsed. It is empty when parsing the binary
+	// representation.
+	Input string
+	// Offset is the position at which the problem was found. It is a byte
+	// offset into Input, or into the binary representation if Input is empty.
+	Offset int
+	// Component is the index of the offending component, or -1 if the error
+	// is about the input as a whole.
+	Component int
+	// Protocol is the name of the offending component's protocol. For unknown
+	// protocols in string input, it's the name that failed to resolve.
+	Protocol string
+	// Value is the string value that failed to parse, if any.
+	Value string
+	// Err is the cause of the error. It wraps one of the Err* sentinels of
+	// this package, and possibly the error returned by a Transcoder.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	b.WriteString("failed to parse multiaddr")
+	if e.Input != "" {
+		fmt.Fprintf(&b, " %q", e.Input)
+	}
+	b.WriteString(": ")
+	switch {
+	case e.Component < 0:
+	case e.Protocol != "":
+		fmt.Fprintf(&b, "component %d (%s) at offset %d: ", e.Component, e.Protocol, e.Offset)
+	default:
+		fmt.Fprintf(&b, "component %d at offset %d: ", e.Component, e.Offset)
+	}
+	if e.Err != nil {
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// MatchError is returned by Multiaddr.ExplainMatch when a multiaddr doesn't
+// match a pattern.
+type MatchError struct {
+	Addr Multiaddr
+	// Diagnosis tells where matching failed, and which protocols would have
+	// been accepted there.
+	Diagnosis meg.Diagnosis
+}
+
+func (e *MatchError) Error() string {
+	return fmt.Sprintf("%s doesn't match: %s", e.Addr, e.Diagnosis.Format(protocolName))
+}
+
+// Unwrap returns the error of a capture func, if any.
+func (e *MatchError) Unwrap() error {
+	return e.Diagnosis.Err
+}
+
+// withPosition moves a component level ParseError to the position of the
+// component within the whole multiaddr.
+func withPosition(err error, offset, index int) error {
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		pe.Offset += offset
+		pe.Component = index
+	}
+	return err
+}
+
+func invalidValueError(value string, err error) error {
+	if value == "" {
+		return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+	}
+	return fmt.Errorf("%w %q: %w", ErrInvalidValue, value, err)
+}
+
+func varintError(err error) error {
+	if errors.Is(err, varint.ErrUnderflow) {
+		return fmt.Errorf("%w: %w", ErrTruncated, err)
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidVarint, err)
+}
@@ -0,0 +1,100 @@
+package multiaddr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrorString(t *testing.T) {
+	for _, tc := range []struct {
+		in        string
+		cause     error
+		offset    int
+		component int
+		protocol  string
+		value     string
+	}{
+		{in: "", cause: ErrEmptyMultiaddr, component: -1},
+		{in: "ip4/1.2.3.4", cause: ErrMissingLeadingSlash, component: -1},
+		{in: "/", cause: ErrEmptyMultiaddr, component: -1},
+		{in: "/ip4/1.2.3.4/foo/1", cause: ErrUnknownProtocol, offset: 13, component: 1, protocol: "foo"},
+		{in: "/ip4/1.2.3.4/tcp", cause: ErrTruncated, offset: 16, component: 1, protocol: "tcp"},
+		{in: "/ip4/1.2.3.4/tcp/99999", cause: ErrInvalidValue, offset: 17, component: 1, protocol: "tcp", value: "99999"},
+		{in: "/ip4/1.2.3.4/ipcidr/256", cause: ErrInvalidValue, offset: 20, component: 1, protocol: "ipcidr", value: "256"},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			_, err := NewMultiaddr(tc.in)
+			require.ErrorIs(t, err, tc.cause)
+
+			var pe *ParseError
+			require.ErrorAs(t, err, &pe)
+			require.Equal(t, tc.in, pe.Input)
+			require.Equal(t, tc.offset, pe.Offset)
+			require.Equal(t, tc.component, pe.Component)
+			require.Equal(t, tc.protocol, pe.Protocol)
+			require.Equal(t, tc.value, pe.Value)
+			if tc.value != "" {
+				require.Equal(t, tc.value, tc.in[pe.Offset:pe.Offset+len(pe.Value)])
+			}
+		})
+	}
+}
+
+func TestParseErrorBytes(t *testing.T) {
+	ip4 := StringCast("/ip4/1.2.3.4").Bytes()
+	tcp := StringCast("/tcp/1234").Bytes()
+	unix := StringCast("/unix/a").Bytes()
+	join := func(bs ...[]byte) []byte {
+		var out []byte
+		for _, b := range bs {
+			out = append(out, b...)
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		name      string
+		in        []byte
+		cause     error
+		offset    int
+		component int
+	}{
+		{name: "empty", in: nil, cause: ErrEmptyMultiaddr, component: -1},
+		{name: "unknown protocol", in: join(ip4, []byte{0x7f}), cause: ErrUnknownProtocol, offset: 5, component: 1},
+		{name: "truncated code", in: join(ip4, []byte{0x80}), cause: ErrTruncated, offset: 5, component: 1},
+		{name: "truncated value", in: join(ip4, tcp[:2]), cause: ErrTruncated, offset: 6, component: 1},
+		{name: "invalid value", in: join(ip4, []byte{0xa5, 0x03, 0x01, 0x00}), cause: ErrInvalidValue, offset: 8, component: 1},
+		{name: "after path", in: join(unix, tcp), cause: ErrTrailingData, offset: len(unix), component: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, err := range []error{
+				func() error { _, err := NewMultiaddrBytes(tc.in); return err }(),
+				NewMultiaddrView(tc.in).Validate(),
+			} {
+				require.ErrorIs(t, err, tc.cause)
+				var pe *ParseError
+				require.ErrorAs(t, err, &pe)
+				require.Empty(t, pe.Input)
+				require.Equal(t, tc.offset, pe.Offset)
+				require.Equal(t, tc.component, pe.Component)
+			}
+		})
+	}
+}
+
+func TestNewComponentParseError(t *testing.T) {
+	_, err := NewComponent("foo", "bar")
+	require.ErrorIs(t, err, ErrUnknownProtocol)
+
+	_, err = NewComponent("tcp", "abc")
+	require.ErrorIs(t, err, ErrInvalidValue)
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	require.Equal(t, "/tcp/abc", pe.Input)
+	require.Equal(t, "abc", pe.Input[pe.Offset:])
+
+	_, err = NewComponent("p2p-circuit", "abc")
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
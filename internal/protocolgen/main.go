@@ -0,0 +1,244 @@
+// Command protocolgen generates the protocol table of the multiaddr package.
+//
+// It reads the multiaddr entries of the multicodec table
+// (https://github.com/multiformats/multicodec/blob/master/table.csv) and the
+// hand-written protocol definitions in spec.go, and writes the P_* constants
+// and Protocol variables. It fails if a protocol in spec.go is missing from
+// the table, or if its code doesn't match the table.
+//
+// table.csv holds the header and the multiaddr rows of the upstream table, in
+// the upstream format. The generator ignores rows that aren't tagged
+// multiaddr, so the full upstream table works too. To replace table.csv with
+// the full upstream table, run from the root of the module:
+//
+//	go run ./internal/protocolgen -update
+//
+// which downloads it before generating.
+//
+// Run it with go generate from the root of the module.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// upstreamTable is the URL of the raw multicodec table.
+const upstreamTable = "https://raw.githubusercontent.com/multiformats/multicodec/master/table.csv"
+
+// entry is a row of the multicodec table.
+type entry struct {
+	Name        string
+	Tag         string
+	Code        uint64
+	Status      string
+	Description string
+}
+
+func main() {
+	tablePath := flag.String("table", "internal/protocolgen/table.csv", "path to the multicodec table")
+	outPath := flag.String("out", "protocols_gen.go", "path to the generated file")
+	update := flag.Bool("update", false, "download the upstream multicodec table before generating")
+	flag.Parse()
+
+	if *update {
+		if err := download(upstreamTable, *tablePath); err != nil {
+			log.Fatalf("updating %s: %s", *tablePath, err)
+		}
+	}
+
+	f, err := os.Open(*tablePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	table, err := readTable(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("reading %s: %s", *tablePath, err)
+	}
+	if err := check(table, protocols); err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(protocols)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*outPath, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// download writes the body of url to path, unmodified.
+func download(url, path string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// Make sure the new table parses before replacing the old one.
+	if _, err := readTable(bytes.NewReader(body)); err != nil {
+		return err
+	}
+	return os.WriteFile(path, body, 0o644)
+}
+
+// readTable parses a multicodec table, indexed by name. Names only have to be
+// unique among the rows tagged multiaddr, which take precedence over rows
+// with other tags.
+func readTable(r io.Reader) (map[string]entry, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = 5
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || records[0][0] != "name" || records[0][2] != "code" {
+		return nil, fmt.Errorf("missing header")
+	}
+
+	table := make(map[string]entry, len(records)-1)
+	for _, rec := range records[1:] {
+		for i := range rec {
+			rec[i] = strings.TrimSpace(rec[i])
+		}
+		code, err := strconv.ParseUint(rec[2], 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid code for %s: %w", rec[0], err)
+		}
+		if prev, ok := table[rec[0]]; ok {
+			if prev.Tag == "multiaddr" && rec[1] == "multiaddr" {
+				return nil, fmt.Errorf("duplicate entry for %s", rec[0])
+			}
+			if prev.Tag == "multiaddr" || rec[1] != "multiaddr" {
+				continue
+			}
+		}
+		table[rec[0]] = entry{
+			Name:        rec[0],
+			Tag:         rec[1],
+			Code:        code,
+			Status:      rec[3],
+			Description: rec[4],
+		}
+	}
+	return table, nil
+}
+
+// check verifies that the hand-written protocols agree with the table.
+func check(table map[string]entry, protocols []protocol) error {
+	names := make(map[string]bool)
+	codes := make(map[uint64]string)
+	consts := make(map[string]bool)
+	for _, p := range protocols {
+		e, ok := table[p.Name]
+		if !ok {
+			return fmt.Errorf("%s is not in the multicodec table, register it before adding it", p.Name)
+		}
+		if e.Tag != "multiaddr" {
+			return fmt.Errorf("%s is tagged %q in the multicodec table, not multiaddr", p.Name, e.Tag)
+		}
+		if e.Code != p.Code {
+			return fmt.Errorf("%s has code 0x%x, but the multicodec table says 0x%x", p.Name, p.Code, e.Code)
+		}
+		if e.Status == "deprecated" && !p.Deprecated {
+			return fmt.Errorf("%s is deprecated in the multicodec table", p.Name)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("%s is defined twice", p.Name)
+		}
+		if other, ok := codes[p.Code]; ok {
+			return fmt.Errorf("%s and %s have the same code 0x%x", p.Name, other, p.Code)
+		}
+		names[p.Name] = true
+		codes[p.Code] = p.Name
+		consts[p.Const] = true
+	}
+	for _, p := range protocols {
+		for _, r := range p.Replacement {
+			if !consts[r] {
+				return fmt.Errorf("%s is replaced by unknown protocol %s", p.Name, r)
+			}
+		}
+	}
+	return nil
+}
+
+var tmpl = template.Must(template.New("").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+	"hex":   func(c uint64) string { return fmt.Sprintf("0x%04x", c) },
+	"join":  strings.Join,
+}).Parse(`// Code generated by internal/protocolgen from the multicodec table; DO NOT EDIT.
+
+package multiaddr
+
+const (
+{{- range .}}
+	{{.Const}} = {{hex .Code}}{{if .Comment}} // {{.Comment}}{{end}}
+{{- end}}
+)
+
+var (
+{{- range .}}
+	{{.Var}} = Protocol{
+		Name: {{quote .Name}},
+		Code: {{.Const}},
+		VCode: CodeToVarint({{.Const}}),
+		{{- if .Size}}
+		Size: {{.Size}},
+		{{- end}}
+		{{- if .Path}}
+		Path: true,
+		{{- end}}
+		{{- if .Transcoder}}
+		Transcoder: {{.Transcoder}},
+		{{- end}}
+		{{- if .Aliases}}
+		Aliases: []string{ {{- range $i, $a := .Aliases}}{{if $i}}, {{end}}{{quote $a}}{{end -}} },
+		{{- end}}
+		{{- if .Deprecated}}
+		Deprecated: true,
+		{{- end}}
+		{{- if .Replacement}}
+		Replacement: []int{ {{- join .Replacement ", " -}} },
+		{{- end}}
+		{{- if .Layer}}
+		Layer: {{.Layer}},
+		{{- end}}
+	}
+{{- end}}
+)
+
+// builtinProtocols are registered with the default registry, in order.
+var builtinProtocols = []Protocol{
+{{- range .}}
+	{{.Var}},
+{{- end}}
+}
+`))
+
+func generate(protocols []protocol) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, protocols); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func loadTable(t *testing.T) map[string]entry {
+	t.Helper()
+	f, err := os.Open("table.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	table, err := readTable(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestGeneratedFileIsUpToDate(t *testing.T) {
+	if err := check(loadTable(t), protocols); err != nil {
+		t.Fatal(err)
+	}
+	want, err := generate(protocols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../../protocols_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("protocols_gen.go is out of date, run go generate")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	table := loadTable(t)
+	tcp := protocol{Name: "tcp", Code: 0x06, Const: "P_TCP"}
+
+	for _, tc := range []struct {
+		name      string
+		protocols []protocol
+		err       string
+	}{
+		{"ok", []protocol{tcp}, ""},
+		{"missing", []protocol{{Name: "tcp2", Code: 0x06}}, "not in the multicodec table"},
+		{"mismatch", []protocol{{Name: "tcp", Code: 0x07}}, "but the multicodec table says 0x6"},
+		{"duplicate", []protocol{tcp, tcp}, "defined twice"},
+		{"deprecated", []protocol{{Name: "p2p-webrtc-direct", Code: 0x0114}}, "deprecated"},
+		{"unknown replacement", []protocol{{Name: "wss", Code: 0x01de, Deprecated: true, Replacement: []string{"P_TLS"}}}, "unknown protocol P_TLS"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := check(table, tc.protocols)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestReadUpstreamTable(t *testing.T) {
+	// Rows in the upstream format, with other tags and quoted descriptions.
+	const upstream = `name,                           tag,            code,           status,     description
+identity,                       multihash,      0x00,           permanent,  raw binary
+tcp,                            multiaddr,      0x06,           permanent,
+sha2-256,                       multihash,      0x12,           permanent,  "sha2-256, with a comma"
+tcp,                            ipld,           0x99,           draft,      same name as tcp
+`
+	table, err := readTable(strings.NewReader(upstream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := table["tcp"]; e.Tag != "multiaddr" || e.Code != 0x06 {
+		t.Fatalf("unexpected tcp entry %+v", e)
+	}
+	if e := table["sha2-256"]; e.Description != "sha2-256, with a comma" {
+		t.Fatalf("unexpected sha2-256 entry %+v", e)
+	}
+	if err := check(table, []protocol{{Name: "identity", Code: 0x00}}); err == nil || !strings.Contains(err.Error(), "not multiaddr") {
+		t.Fatalf("expected a tag error, got %v", err)
+	}
+
+	_, err = readTable(strings.NewReader(upstream + "tcp, multiaddr, 0x07, draft,\n"))
+	if err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("expected a duplicate error, got %v", err)
+	}
+}
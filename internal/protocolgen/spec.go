@@ -0,0 +1,69 @@
+package main
+
+// protocol describes a protocol implemented by this module. Everything but the
+// name and code is hand-written. The name and code are checked against the
+// multicodec table.
+type protocol struct {
+	// Name is the multicodec name.
+	Name string
+	// Code is the code the implementation expects. It must match the table.
+	Code uint64
+	// Const is the name of the P_* constant.
+	Const string
+	// Var is the name of the Protocol variable.
+	Var string
+	// Comment is appended to the constant.
+	Comment string
+
+	// The remaining fields are Go expressions copied into the Protocol
+	// definition. Empty fields are omitted.
+	Size        string
+	Path        bool
+	Transcoder  string
+	Layer       string
+	Deprecated  bool
+	Aliases     []string
+	Replacement []string
+}
+
+// protocols are registered in this order.
+var protocols = []protocol{
+	{Name: "ip4", Code: 0x04, Const: "P_IP4", Var: "protoIP4", Size: "32", Transcoder: "TranscoderIP4", Layer: "LayerNetwork"},
+	{Name: "tcp", Code: 0x06, Const: "P_TCP", Var: "protoTCP", Size: "16", Transcoder: "TranscoderPort", Layer: "LayerTransport"},
+	{Name: "dns", Code: 0x35, Const: "P_DNS", Var: "protoDNS", Comment: "4 or 6", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderDns", Layer: "LayerNetwork"},
+	{Name: "dns4", Code: 0x36, Const: "P_DNS4", Var: "protoDNS4", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderDns", Layer: "LayerNetwork"},
+	{Name: "dns6", Code: 0x37, Const: "P_DNS6", Var: "protoDNS6", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderDns", Layer: "LayerNetwork"},
+	{Name: "dnsaddr", Code: 0x38, Const: "P_DNSADDR", Var: "protoDNSADDR", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderDns", Layer: "LayerNetwork"},
+	{Name: "udp", Code: 0x0111, Const: "P_UDP", Var: "protoUDP", Size: "16", Transcoder: "TranscoderPort", Layer: "LayerTransport"},
+	{Name: "dccp", Code: 0x21, Const: "P_DCCP", Var: "protoDCCP", Size: "16", Transcoder: "TranscoderPort", Layer: "LayerTransport"},
+	{Name: "ip6", Code: 0x29, Const: "P_IP6", Var: "protoIP6", Size: "128", Transcoder: "TranscoderIP6", Layer: "LayerNetwork"},
+	{Name: "ip6zone", Code: 0x2a, Const: "P_IP6ZONE", Var: "protoIP6ZONE", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderIP6Zone", Layer: "LayerNetwork"},
+	{Name: "ipcidr", Code: 0x2b, Const: "P_IPCIDR", Var: "protoIPCIDR", Size: "8", Transcoder: "TranscoderIPCIDR", Layer: "LayerNetwork"},
+	{Name: "sctp", Code: 0x84, Const: "P_SCTP", Var: "protoSCTP", Size: "16", Transcoder: "TranscoderPort", Layer: "LayerTransport"},
+	{Name: "p2p-circuit", Code: 0x0122, Const: "P_CIRCUIT", Var: "protoCIRCUIT", Layer: "LayerPeer"},
+	{Name: "onion", Code: 0x01bc, Const: "P_ONION", Var: "protoONION2", Comment: "also for backwards compatibility", Size: "96", Transcoder: "TranscoderOnion", Layer: "LayerNetwork", Deprecated: true},
+	{Name: "onion3", Code: 0x01bd, Const: "P_ONION3", Var: "protoONION3", Size: "296", Transcoder: "TranscoderOnion3", Layer: "LayerNetwork"},
+	{Name: "garlic64", Code: 0x01be, Const: "P_GARLIC64", Var: "protoGARLIC64", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderGarlic64", Layer: "LayerNetwork"},
+	{Name: "garlic32", Code: 0x01bf, Const: "P_GARLIC32", Var: "protoGARLIC32", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderGarlic32", Layer: "LayerNetwork"},
+	{Name: "utp", Code: 0x012e, Const: "P_UTP", Var: "protoUTP", Layer: "LayerTransport"},
+	{Name: "udt", Code: 0x012d, Const: "P_UDT", Var: "protoUDT", Layer: "LayerTransport"},
+	{Name: "quic", Code: 0x01cc, Const: "P_QUIC", Var: "protoQUIC", Comment: "draft-29, use quic-v1 instead", Layer: "LayerTransport", Deprecated: true},
+	{Name: "quic-v1", Code: 0x01cd, Const: "P_QUIC_V1", Var: "protoQUICV1", Layer: "LayerTransport"},
+	{Name: "webtransport", Code: 0x01d1, Const: "P_WEBTRANSPORT", Var: "protoWEBTRANSPORT", Layer: "LayerTransport"},
+	{Name: "certhash", Code: 0x01d2, Const: "P_CERTHASH", Var: "protoCERTHASH", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderCertHash", Layer: "LayerSecurity"},
+	{Name: "http", Code: 0x01e0, Const: "P_HTTP", Var: "protoHTTP", Layer: "LayerApplication"},
+	{Name: "http-path", Code: 0x01e1, Const: "P_HTTP_PATH", Var: "protoHTTPPath", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderHTTPPath", Layer: "LayerApplication"},
+	{Name: "https", Code: 0x01bb, Const: "P_HTTPS", Var: "protoHTTPS", Comment: "deprecated alias for /tls/http", Layer: "LayerApplication", Deprecated: true, Replacement: []string{"P_TLS", "P_HTTP"}},
+	{Name: "p2p", Code: 0x01a5, Const: "P_P2P", Var: "protoP2P", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderP2P", Layer: "LayerPeer", Aliases: []string{"ipfs"}},
+	{Name: "unix", Code: 0x0190, Const: "P_UNIX", Var: "protoUNIX", Size: "LengthPrefixedVarSize", Path: true, Transcoder: "TranscoderUnix", Layer: "LayerNetwork"},
+	{Name: "p2p-webrtc-direct", Code: 0x0114, Const: "P_P2P_WEBRTC_DIRECT", Var: "protoP2P_WEBRTC_DIRECT", Comment: "Deprecated. use webrtc-direct instead", Layer: "LayerTransport", Deprecated: true},
+	{Name: "tls", Code: 0x01c0, Const: "P_TLS", Var: "protoTLS", Layer: "LayerSecurity"},
+	{Name: "sni", Code: 0x01c1, Const: "P_SNI", Var: "protoSNI", Size: "LengthPrefixedVarSize", Transcoder: "TranscoderDns", Layer: "LayerSecurity"},
+	{Name: "noise", Code: 0x01c6, Const: "P_NOISE", Var: "protoNOISE", Layer: "LayerSecurity"},
+	{Name: "ws", Code: 0x01dd, Const: "P_WS", Var: "protoWS", Layer: "LayerTransport"},
+	{Name: "wss", Code: 0x01de, Const: "P_WSS", Var: "protoWSS", Comment: "deprecated alias for /tls/ws", Layer: "LayerTransport", Deprecated: true, Replacement: []string{"P_TLS", "P_WS"}},
+	{Name: "plaintextv2", Code: 0x706c61, Const: "P_PLAINTEXTV2", Var: "protoPlaintextV2", Layer: "LayerSecurity"},
+	{Name: "webrtc-direct", Code: 0x0118, Const: "P_WEBRTC_DIRECT", Var: "protoWebRTCDirect", Layer: "LayerTransport"},
+	{Name: "webrtc", Code: 0x0119, Const: "P_WEBRTC", Var: "protoWebRTC", Layer: "LayerTransport"},
+	{Name: "memory", Code: 0x0309, Const: "P_MEMORY", Var: "protoMemory", Size: "64", Transcoder: "TranscoderMemory", Layer: "LayerTransport"},
+}
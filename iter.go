@@ -0,0 +1,100 @@
+package multiaddr
+
+import "iter"
+
+// All returns an iterator over the index and value of each component of m.
+func (m Multiaddr) All() iter.Seq2[int, Component] {
+	return func(yield func(int, Component) bool) {
+		for i, c := range m {
+			if !yield(i, c) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the components of m, from the last one to
+// the first one.
+func (m Multiaddr) Backward() iter.Seq2[int, Component] {
+	return func(yield func(int, Component) bool) {
+		for i := len(m) - 1; i >= 0; i-- {
+			if !yield(i, m[i]) {
+				return
+			}
+		}
+	}
+}
+
+// ProtocolCodes returns an iterator over the protocol codes of m's components.
+// Unlike Protocols, it doesn't allocate.
+func (m Multiaddr) ProtocolCodes() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for _, c := range m {
+			if !yield(c.Code()) {
+				return
+			}
+		}
+	}
+}
+
+// SplitSeq returns an iterator over the parts of m, splitting before each
+// component for which cb returns true. For example, splitting
+// /ip4/1.2.3.4/tcp/1/p2p-circuit/p2p/QmFoo on p2p-circuit yields
+// /ip4/1.2.3.4/tcp/1 and /p2p-circuit/p2p/QmFoo. Empty parts are skipped.
+//
+// The parts share m's backing array. Use Multiaddr.Multiaddr or copy them
+// before appending to them.
+func (m Multiaddr) SplitSeq(cb func(Component) bool) iter.Seq[Multiaddr] {
+	return func(yield func(Multiaddr) bool) {
+		start := 0
+		for i, c := range m {
+			if i == start || !cb(c) {
+				continue
+			}
+			if !yield(m[start:i:i]) {
+				return
+			}
+			start = i
+		}
+		if start < len(m) {
+			yield(m[start:])
+		}
+	}
+}
+
+// BytesSeq returns an iterator that parses each binary multiaddr in addrs.
+// Invalid addresses yield an error, and iteration continues with the next
+// address.
+func BytesSeq(addrs [][]byte) iter.Seq2[Multiaddr, error] {
+	return defaultParser.BytesSeq(addrs)
+}
+
+// BytesSeq is like the package level BytesSeq, but enforces the Parser's
+// options.
+func (pr *Parser) BytesSeq(addrs [][]byte) iter.Seq2[Multiaddr, error] {
+	return func(yield func(Multiaddr, error) bool) {
+		for _, b := range addrs {
+			if !yield(pr.NewMultiaddrBytes(b)) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the components of v. Iteration stops after the
+// first error, which is yielded with a zero ComponentView.
+func (v MultiaddrView) All() iter.Seq2[ComponentView, error] {
+	return func(yield func(ComponentView, error) bool) {
+		stopped := false
+		err := v.ForEach(func(c ComponentView) bool {
+			if !yield(c, nil) {
+				stopped = true
+				return false
+			}
+			return true
+		})
+		if err != nil && !stopped {
+			yield(ComponentView{}, err)
+		}
+	}
+}
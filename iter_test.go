@@ -0,0 +1,139 @@
+package multiaddr
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterators(t *testing.T) {
+	m := StringCast("/ip4/1.2.3.4/tcp/1/tls/ws")
+
+	var forward []string
+	for i, c := range m.All() {
+		require.Equal(t, m[i], c)
+		forward = append(forward, c.Protocol().Name)
+	}
+	require.Equal(t, []string{"ip4", "tcp", "tls", "ws"}, forward)
+
+	var backward []int
+	for i := range m.Backward() {
+		backward = append(backward, i)
+	}
+	require.Equal(t, []int{3, 2, 1, 0}, backward)
+
+	require.Equal(t, []int{P_IP4, P_TCP, P_TLS, P_WS}, slices.Collect(m.ProtocolCodes()))
+	require.True(t, slices.Contains(slices.Collect(m.ProtocolCodes()), P_TLS))
+
+	byIndex := maps.Collect(m.All())
+	require.Len(t, byIndex, 4)
+	c := byIndex[2]
+	require.Equal(t, P_TLS, c.Code())
+
+	// Short circuit
+	for i := range m.All() {
+		if i == 1 {
+			break
+		}
+	}
+	for range m.Backward() {
+		break
+	}
+	for range m.ProtocolCodes() {
+		break
+	}
+
+	var empty Multiaddr
+	require.Empty(t, slices.Collect(empty.ProtocolCodes()))
+}
+
+func TestProtocolCodesDoesNotAllocate(t *testing.T) {
+	m := StringCast("/ip4/1.2.3.4/udp/1/quic-v1/webtransport")
+	allocs := testing.AllocsPerRun(100, func() {
+		for code := range m.ProtocolCodes() {
+			if code == P_WEBTRANSPORT {
+				break
+			}
+		}
+	})
+	require.Zero(t, allocs)
+}
+
+func TestSplitSeq(t *testing.T) {
+	isCircuit := func(c Component) bool { return c.Code() == P_CIRCUIT }
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{"/ip4/1.2.3.4/tcp/1", []string{"/ip4/1.2.3.4/tcp/1"}},
+		{
+			"/ip4/1.2.3.4/tcp/1/p2p-circuit/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+			[]string{"/ip4/1.2.3.4/tcp/1", "/p2p-circuit/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC"},
+		},
+		{"/p2p-circuit/p2p-circuit/ip4/1.2.3.4", []string{"/p2p-circuit", "/p2p-circuit/ip4/1.2.3.4"}},
+	} {
+		var got []string
+		for part := range StringCast(tc.in).SplitSeq(isCircuit) {
+			got = append(got, part.String())
+		}
+		require.Equal(t, tc.want, got, tc.in)
+	}
+
+	var empty Multiaddr
+	require.Empty(t, slices.Collect(empty.SplitSeq(isCircuit)))
+
+	// Appending to a part doesn't clobber the next one.
+	m := StringCast("/ip4/1.2.3.4/p2p-circuit/ip4/5.6.7.8")
+	parts := slices.Collect(m.SplitSeq(isCircuit))
+	_ = append(parts[0], parts[0][0])
+	require.Equal(t, "/ip4/1.2.3.4/p2p-circuit/ip4/5.6.7.8", m.String())
+}
+
+func TestBytesSeq(t *testing.T) {
+	addrs := [][]byte{
+		StringCast("/ip4/1.2.3.4/tcp/1").Bytes(),
+		{0xff},
+		StringCast("/ip6/::1/udp/1/quic-v1").Bytes(),
+	}
+	var good []string
+	var errs int
+	for m, err := range BytesSeq(addrs) {
+		if err != nil {
+			errs++
+			continue
+		}
+		good = append(good, m.String())
+	}
+	require.Equal(t, []string{"/ip4/1.2.3.4/tcp/1", "/ip6/::1/udp/1/quic-v1"}, good)
+	require.Equal(t, 1, errs)
+
+	pr := NewParser(ParseOptions{AllowedProtocols: []int{P_IP4, P_TCP}})
+	for m, err := range pr.BytesSeq(addrs[2:]) {
+		require.Nil(t, m)
+		require.ErrorIs(t, err, ErrProtocolNotAllowed)
+	}
+}
+
+func TestMultiaddrViewAll(t *testing.T) {
+	m := StringCast("/ip4/1.2.3.4/tcp/1")
+	var codes []int
+	for c, err := range NewMultiaddrView(m.Bytes()).All() {
+		require.NoError(t, err)
+		codes = append(codes, c.Code())
+	}
+	require.Equal(t, []int{P_IP4, P_TCP}, codes)
+
+	var errs []error
+	for _, err := range NewMultiaddrView(append(m.Bytes(), 0x7f)).All() {
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 3)
+	require.NoError(t, errs[1])
+	require.ErrorIs(t, errs[2], ErrUnknownProtocol)
+
+	for range NewMultiaddrView(append(m.Bytes(), 0x7f)).All() {
+		break
+	}
+}
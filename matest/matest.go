@@ -81,3 +81,12 @@ func (m MultiaddrMatcher) Matches(x interface{}) bool {
 	}
 	return false
 }
+
+// AssertMultiaddrSetMatches asserts that actual contains exactly the expected
+// multiaddrs, in any order.
+func AssertMultiaddrSetMatches(t TestingT, expected []multiaddr.Multiaddr, actual *multiaddr.MultiaddrSet) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	return AssertMultiaddrsMatch(t, expected, actual.Sorted())
+}
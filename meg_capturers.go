@@ -20,29 +20,26 @@ func CaptureAddrPort(network *string, ipPort *netip.AddrPort) (capturePattern me
 			return fmt.Errorf("invalid network: %s", s.Value())
 		}
 
-		port := binary.BigEndian.Uint16(s.RawValue())
-		*ipPort = netip.AddrPortFrom(ipOnly, port)
+		v := s.RawValue()
+		if len(v) != 2 {
+			return fmt.Errorf("invalid port length %d", len(v))
+		}
+		*ipPort = netip.AddrPortFrom(ipOnly, binary.BigEndian.Uint16(v))
+		return nil
+	}
+	captureIP := func(s meg.Matchable) error {
+		var ok bool
+		ipOnly, ok = netip.AddrFromSlice(s.RawValue())
+		if !ok {
+			return fmt.Errorf("invalid IP address: %s", s.Value())
+		}
 		return nil
 	}
 
 	pattern := meg.Cat(
 		meg.Or(
-			meg.CaptureWithF(P_IP4, func(s meg.Matchable) error {
-				var ok bool
-				ipOnly, ok = netip.AddrFromSlice(s.RawValue())
-				if !ok {
-					return fmt.Errorf("invalid ip4 address: %s", s.Value())
-				}
-				return nil
-			}),
-			meg.CaptureWithF(P_IP6, func(s meg.Matchable) error {
-				var ok bool
-				ipOnly, ok = netip.AddrFromSlice(s.RawValue())
-				if !ok {
-					return fmt.Errorf("invalid ip6 address: %s", s.Value())
-				}
-				return nil
-			}),
+			meg.CaptureWithF(P_IP4, captureIP),
+			meg.CaptureWithF(P_IP6, captureIP),
 		),
 		meg.Or(
 			meg.CaptureWithF(P_UDP, capturePort),
@@ -52,3 +49,35 @@ func CaptureAddrPort(network *string, ipPort *netip.AddrPort) (capturePattern me
 
 	return pattern
 }
+
+// CaptureGroup sets m to the components matched by p. When matching a
+// Multiaddr, m is a slice of it, so nothing is copied, and it's capped so that
+// appending to m doesn't modify the matched multiaddr. When matching
+// ComponentViews, the components are copied into m. m is nil if p matched no
+// components. If p is repeated, m is set to its last repetition.
+func CaptureGroup(p meg.Pattern, m *Multiaddr) meg.Pattern {
+	p = meg.CaptureGroupSlice(p, func(group []Component) error {
+		if len(group) == 0 {
+			*m = nil
+			return nil
+		}
+		*m = group
+		return nil
+	})
+	return meg.CaptureGroupSlice(p, func(group []ComponentView) error {
+		if len(group) == 0 {
+			*m = nil
+			return nil
+		}
+		out := make(Multiaddr, len(group))
+		for i, v := range group {
+			c, err := v.Component()
+			if err != nil {
+				return err
+			}
+			out[i] = *c
+		}
+		*m = out
+		return nil
+	})
+}
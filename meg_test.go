@@ -1,6 +1,7 @@
 package multiaddr
 
 import (
+	"errors"
 	"net/netip"
 	"testing"
 
@@ -69,4 +70,222 @@ func TestCaptureAddrPort(t *testing.T) {
 	if addrPort.String() != "1.2.3.4:8231" {
 		t.Fatal("unexpected ipPort", addrPort)
 	}
+
+	// It works on views too.
+	addrPort = netip.AddrPort{}
+	found, err = meg.Match(meg.PatternToMatcher(CaptureAddrPort(&network, &addrPort), meg.ZeroOrMore(meg.Any)), componentViews(t, m))
+	if err != nil || !found {
+		t.Fatal("failed to match views", err)
+	}
+	if addrPort.String() != "1.2.3.4:8231" {
+		t.Fatal("unexpected ipPort", addrPort)
+	}
+}
+
+// componentViews returns views of the components of m.
+func componentViews(t *testing.T, m Multiaddr) []ComponentView {
+	t.Helper()
+	var views []ComponentView
+	err := NewMultiaddrView(m.Bytes()).ForEach(func(c ComponentView) bool {
+		views = append(views, c)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return views
+}
+
+func TestCaptureGroup(t *testing.T) {
+	m := StringCast("/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC")
+
+	var relay, dest Multiaddr
+	found, err := m.Match(
+		CaptureGroup(meg.ZeroOrMore(meg.Any), &relay),
+		meg.Val(P_CIRCUIT),
+		CaptureGroup(meg.ZeroOrMore(meg.Any), &dest),
+	)
+	if err != nil || !found {
+		t.Fatal("failed to match", err)
+	}
+	if relay.String() != "/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC" {
+		t.Fatal("unexpected relay", relay)
+	}
+	if dest.String() != "/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC" {
+		t.Fatal("unexpected destination", dest)
+	}
+	// The captures share the components of m.
+	if &relay[0] != &m[0] || &dest[0] != &m[len(m)-1] {
+		t.Fatal("expected the captures to slice m")
+	}
+	// Appending to a capture doesn't modify m.
+	_ = append(relay, StringCast("/ws")[0])
+	if m[3].Code() != P_CIRCUIT {
+		t.Fatal("appending to the capture modified m")
+	}
+
+	var suffix Multiaddr
+	found, err = StringCast("/ip4/1.2.3.4/tcp/1").Match(
+		meg.Val(P_IP4),
+		meg.Val(P_TCP),
+		CaptureGroup(meg.ZeroOrMore(meg.Any), &suffix),
+	)
+	if err != nil || !found {
+		t.Fatal("failed to match", err)
+	}
+	if suffix != nil {
+		t.Fatal("expected an empty capture", suffix)
+	}
+
+	// A matcher built once slices each multiaddr it matches.
+	matcher := meg.PatternToMatcher(
+		CaptureGroup(meg.ZeroOrMore(meg.Any), &relay),
+		meg.Val(P_CIRCUIT),
+		CaptureGroup(meg.ZeroOrMore(meg.Any), &dest),
+	)
+	for _, s := range []string{
+		"/ip4/5.6.7.8/udp/1/quic-v1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		"/dns/example.com/tcp/2/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit",
+	} {
+		other := StringCast(s)
+		found, err = meg.Match(matcher, other)
+		if err != nil || !found {
+			t.Fatal("failed to match", err)
+		}
+		if &relay[0] != &other[0] || !relay.Equal(other[:len(relay)]) {
+			t.Fatal("expected the relay to slice", other, relay)
+		}
+	}
+	if dest != nil {
+		t.Fatal("expected an empty destination", dest)
+	}
+
+	// It works on views too, copying the components.
+	relay, dest = nil, nil
+	found, err = meg.Match(matcher, componentViews(t, m))
+	if err != nil || !found {
+		t.Fatal("failed to match views", err)
+	}
+	if !relay.Equal(m[:3]) || !dest.Equal(m[4:]) {
+		t.Fatal("unexpected groups", relay, dest)
+	}
+}
+
+func TestMatchWithPredicates(t *testing.T) {
+	pattern := []meg.Pattern{
+		meg.ValWhere(P_IP4, meg.InPrefix(netip.MustParsePrefix("10.0.0.0/8"))),
+		meg.ValWhere(P_TCP, meg.PortInRange(4000, 5000)),
+	}
+	for _, tc := range []struct {
+		addr  string
+		match bool
+	}{
+		{"/ip4/10.1.2.3/tcp/4001", true},
+		{"/ip4/10.1.2.3/tcp/443", false},
+		{"/ip4/192.168.1.1/tcp/4001", false},
+		{"/ip4/10.1.2.3/udp/4001", false},
+	} {
+		found, err := StringCast(tc.addr).Match(pattern...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != tc.match {
+			t.Errorf("%s: expected %v, got %v", tc.addr, tc.match, found)
+		}
+	}
+
+	found, _ := StringCast("/dns4/a.libp2p.io/tcp/443").Match(
+		meg.Or(meg.ValWhere(P_DNS4, meg.DNSSuffix("libp2p.io")), meg.ValWhere(P_DNS6, meg.DNSSuffix("libp2p.io"))),
+		meg.ValWhere(P_TCP, meg.ValueEquals("443")),
+	)
+	if !found {
+		t.Fatal("failed to match")
+	}
+}
+
+func TestMatchCapturesWithCompiledPattern(t *testing.T) {
+	quicMatcher := MustParsePattern("/{ip4|ip6}:ip/udp:port/quic-v1/p2p:peer?").Matcher(nil)
+
+	caps, found, err := StringCast("/ip6/::1/udp/4001/quic-v1").MatchCaptures(quicMatcher)
+	if err != nil || !found {
+		t.Fatal("failed to match", err)
+	}
+	addr, err := caps.Get("ip").(*Component).AsAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := caps.Get("port").(*Component).AsPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := netip.AddrPortFrom(addr, port).String(); got != "[::1]:4001" {
+		t.Fatal("unexpected address", got)
+	}
+	if caps.Get("peer") != nil {
+		t.Fatal("unexpected peer capture")
+	}
+
+	if _, found, _ := StringCast("/ip4/1.2.3.4/tcp/1").MatchCaptures(quicMatcher); found {
+		t.Fatal("unexpected match")
+	}
+}
+
+func TestMustParsePatternPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	MustParsePattern("/ip4/{tcp")
+}
+
+func TestSearchMultiaddr(t *testing.T) {
+	m := StringCast("/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC")
+
+	thinWaist := meg.PatternToMatcher(meg.Or(meg.Val(P_IP4), meg.Val(P_IP6)), meg.Or(meg.Val(P_TCP), meg.Val(P_UDP)))
+	n, ok, err := meg.MatchPrefix(thinWaist, m)
+	if err != nil || !ok || n != 2 {
+		t.Fatal("expected a thin waist prefix", n, ok, err)
+	}
+
+	span, ok, err := meg.Find(meg.PatternToMatcher(meg.Val(P_CIRCUIT)), m)
+	if err != nil || !ok {
+		t.Fatal("failed to find the circuit", err)
+	}
+	if relay := m[:span.Start].String(); relay != "/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC" {
+		t.Fatal("unexpected relay address", relay)
+	}
+
+	spans, err := meg.FindAll(meg.PatternToMatcher(meg.Val(P_P2P)), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != 2 || spans[0] != (meg.Span{Start: 2, End: 3}) || spans[1] != (meg.Span{Start: 4, End: 5}) {
+		t.Fatal("unexpected spans", spans)
+	}
+}
+
+func TestExplainMatch(t *testing.T) {
+	m := MustParsePattern("/{ip4|ip6}/{tcp|udp}/*").Matcher(nil)
+
+	if err := StringCast("/ip4/1.2.3.4/tcp/1/ws").ExplainMatch(m); err != nil {
+		t.Fatal(err)
+	}
+
+	err := StringCast("/ip4/1.2.3.4/sctp/1").ExplainMatch(m)
+	var matchErr *MatchError
+	if !errors.As(err, &matchErr) {
+		t.Fatalf("expected a MatchError, got %v", err)
+	}
+	if matchErr.Diagnosis.Index != 1 {
+		t.Fatal("unexpected index", matchErr.Diagnosis.Index)
+	}
+	if err.Error() != "/ip4/1.2.3.4/sctp/1 doesn't match: expected tcp or udp after ip4, got sctp" {
+		t.Fatal("unexpected error", err)
+	}
+
+	err = StringCast("/ip6/::1/udp/1").ExplainMatch(m)
+	if err == nil || err.Error() != "/ip6/::1/udp/1 doesn't match: expected any component after udp, got the end" {
+		t.Fatal("unexpected error", err)
+	}
 }
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 
 	"golang.org/x/exp/slices"
@@ -27,39 +26,13 @@ func (m Multiaddr) copy() Multiaddr {
 
 // NewMultiaddr parses and validates an input string, returning a *Multiaddr
 func NewMultiaddr(s string) (a Multiaddr, err error) {
-	defer func() {
-		if e := recover(); e != nil {
-			log.Printf("Panic in NewMultiaddr on input %q: %s", s, e)
-			err = fmt.Errorf("%v", e)
-		}
-	}()
-	b, err := stringToBytes(s)
-	if err != nil {
-		return nil, err
-	}
-	return NewMultiaddrBytes(b)
+	return defaultParser.NewMultiaddr(s)
 }
 
 // NewMultiaddrBytes initializes a Multiaddr from a byte representation.
 // It validates it as an input string.
 func NewMultiaddrBytes(b []byte) (a Multiaddr, err error) {
-	defer func() {
-		if e := recover(); e != nil {
-			log.Printf("Panic in NewMultiaddrBytes on input %q: %s", b, e)
-			err = fmt.Errorf("%v", e)
-		}
-	}()
-	bytesRead, m, err := readMultiaddr(b)
-	if err != nil {
-		return nil, err
-	}
-	if bytesRead != len(b) {
-		return nil, fmt.Errorf("unexpected extra data. %v bytes leftover", len(b)-bytesRead)
-	}
-	if len(m) == 0 {
-		return nil, nil
-	}
-	return m, nil
+	return defaultParser.NewMultiaddrBytes(b)
 }
 
 // Equal tests whether two multiaddrs are equal
@@ -11,7 +11,8 @@ import (
 )
 
 var (
-	flagHelp bool
+	flagHelp  bool
+	flagMatch string
 )
 
 func main() {
@@ -27,6 +28,7 @@ Options:
 	}
 
 	flag.BoolVar(&flagHelp, "h", false, "display help message")
+	flag.StringVar(&flagMatch, "match", "", "check the multiaddr against a pattern, such as /{ip4|ip6}/tcp/*")
 	flag.Parse()
 
 	if flagHelp || len(flag.Args()) == 0 {
@@ -52,9 +54,26 @@ Options:
 		os.Exit(1)
 	}
 
+	if flagMatch != "" {
+		matchCommand(addr, flagMatch)
+		return
+	}
 	infoCommand(addr)
 }
 
+func matchCommand(addr maddr.Multiaddr, pattern string) {
+	expr, err := maddr.ParsePattern(pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pattern error: %s\n", err)
+		os.Exit(1)
+	}
+	if err := addr.ExplainMatch(expr.Matcher(nil)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println("match")
+}
+
 func infoCommand(addr maddr.Multiaddr) {
 	var compsJson []string
 	for _, comp := range addr {
@@ -74,6 +93,8 @@ func infoCommand(addr maddr.Multiaddr) {
 			fmt.Sprintf(`"protocol": "%s", `, comp.Protocol().Name)+
 			fmt.Sprintf(`"codec": "%d", `, comp.Protocol().Code)+
 			fmt.Sprintf(`"uvarint": "0x%x", `, comp.Protocol().VCode)+
+			fmt.Sprintf(`"layer": "%s", `, comp.Protocol().Layer)+
+			fmt.Sprintf(`"deprecated": %t, `, comp.Protocol().Deprecated)+
 			fmt.Sprintf(`"lengthPrefix": "%s"`, lengthPrefix)+
 			`}`)
 	}
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/netip"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	ma "github.com/multiformats/go-multiaddr"
@@ -134,6 +136,140 @@ func FromIP(ip net.IP) (ma.Multiaddr, error) {
 	return FromIPAndZone(ip, "")
 }
 
+// FromAddr converts a netip.Addr to a Multiaddr. The zone of IPv6 addresses is
+// converted to an /ip6zone component. Like FromIP, IPv4-mapped IPv6 addresses
+// are converted to /ip4.
+func FromAddr(addr netip.Addr) (ma.Multiaddr, error) {
+	zone := addr.Zone()
+	if zone == "" {
+		ip, err := ma.NewIPComponent(addr.Unmap())
+		if err != nil {
+			return nil, err
+		}
+		return ma.Multiaddr{*ip}, nil
+	}
+	ip, err := ma.NewIPComponent(addr.WithZone(""))
+	if err != nil {
+		return nil, err
+	}
+	zoneC, err := ma.NewComponent("ip6zone", zone)
+	if err != nil {
+		return nil, err
+	}
+	return ma.Multiaddr{*zoneC, *ip}, nil
+}
+
+// FromAddrPort converts a netip.AddrPort to a Multiaddr. The network must be
+// one of tcp, tcp4, tcp6, udp, udp4 or udp6.
+func FromAddrPort(network string, addrPort netip.AddrPort) (ma.Multiaddr, error) {
+	var code int
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		code = ma.P_TCP
+	case "udp", "udp4", "udp6":
+		code = ma.P_UDP
+	default:
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+	m, err := FromAddr(addrPort.Addr())
+	if err != nil {
+		return nil, err
+	}
+	port, err := ma.NewPortComponent(code, addrPort.Port())
+	if err != nil {
+		return nil, err
+	}
+	return append(m, *port), nil
+}
+
+// ToAddrPort extracts the network and the address of a multiaddr starting with
+// /ip4 or /ip6, optionally preceded by /ip6zone, followed by /tcp or /udp. Any
+// components after the port are ignored. The network is one of tcp4, tcp6,
+// udp4 or udp6, as returned by DialArgs.
+func ToAddrPort(m ma.Multiaddr) (string, netip.AddrPort, error) {
+	zone := ""
+	if len(m) > 0 && m[0].Code() == ma.P_IP6ZONE {
+		zone = m[0].Value()
+		m = m[1:]
+	}
+	if len(m) < 2 {
+		return "", netip.AddrPort{}, errNotIP
+	}
+	addr, err := m[0].AsAddr()
+	if err != nil {
+		return "", netip.AddrPort{}, errNotIP
+	}
+	if zone != "" {
+		if addr.Is4() {
+			return "", netip.AddrPort{}, fmt.Errorf("%s has ip4 with zone", m)
+		}
+		addr = addr.WithZone(zone)
+	}
+	port, err := m[1].AsPort()
+	if err != nil {
+		return "", netip.AddrPort{}, err
+	}
+
+	var network string
+	switch m[1].Code() {
+	case ma.P_TCP:
+		network = "tcp6"
+		if addr.Is4() {
+			network = "tcp4"
+		}
+	case ma.P_UDP:
+		network = "udp6"
+		if addr.Is4() {
+			network = "udp4"
+		}
+	default:
+		return "", netip.AddrPort{}, fmt.Errorf("%s is not a tcp or udp address", m)
+	}
+	return network, netip.AddrPortFrom(addr, port), nil
+}
+
+// FromPrefix converts a netip.Prefix to an /ip4 or /ip6 multiaddr followed by
+// /ipcidr. IPv4-mapped prefixes of at least 96 bits are converted to /ip4.
+func FromPrefix(prefix netip.Prefix) (ma.Multiaddr, error) {
+	if !prefix.IsValid() {
+		return nil, fmt.Errorf("invalid prefix")
+	}
+	addr, bits := prefix.Addr(), prefix.Bits()
+	if addr.Is4In6() && bits >= 96 {
+		addr, bits = addr.Unmap(), bits-96
+	}
+	ip, err := ma.NewIPComponent(addr)
+	if err != nil {
+		return nil, err
+	}
+	cidr, err := ma.NewComponent("ipcidr", strconv.Itoa(bits))
+	if err != nil {
+		return nil, err
+	}
+	return ma.Multiaddr{*ip, *cidr}, nil
+}
+
+// ToPrefix converts a multiaddr starting with /ip4 or /ip6 followed by
+// /ipcidr to a netip.Prefix. Any components after /ipcidr are ignored.
+func ToPrefix(m ma.Multiaddr) (netip.Prefix, error) {
+	if len(m) < 2 {
+		return netip.Prefix{}, errors.New("expected an ip address followed by ipcidr")
+	}
+	addr, err := m[0].AsAddr()
+	if err != nil {
+		return netip.Prefix{}, errNotIP
+	}
+	bits, err := m[1].AsPrefixLen()
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	prefix := netip.PrefixFrom(addr, bits)
+	if !prefix.IsValid() {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix length %d for %s", bits, addr)
+	}
+	return prefix, nil
+}
+
 // ToIP converts a Multiaddr to a net.IP when possible
 func ToIP(addr ma.Multiaddr) (net.IP, error) {
 	var ip net.IP
@@ -2,6 +2,7 @@ package manet
 
 import (
 	"net"
+	"net/netip"
 	"runtime"
 	"testing"
 
@@ -264,3 +265,145 @@ func TestFailMultiaddrToIPNet(t *testing.T) {
 		})
 	}
 }
+
+func TestAddrPortRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		network  string
+		addrPort string
+		ma       string
+		want     string
+	}{
+		{"tcp", "1.2.3.4:4001", "/ip4/1.2.3.4/tcp/4001", "tcp4"},
+		{"udp4", "1.2.3.4:4001", "/ip4/1.2.3.4/udp/4001", "udp4"},
+		{"tcp", "[::1]:4001", "/ip6/::1/tcp/4001", "tcp6"},
+		{"udp", "[fe80::1%eth0]:4001", "/ip6zone/eth0/ip6/fe80::1/udp/4001", "udp6"},
+		{"tcp", "[::ffff:1.2.3.4]:4001", "/ip4/1.2.3.4/tcp/4001", "tcp4"},
+	} {
+		t.Run(tc.ma, func(t *testing.T) {
+			ap := netip.MustParseAddrPort(tc.addrPort)
+			m, err := FromAddrPort(tc.network, ap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.String() != tc.ma {
+				t.Fatalf("expected %s, got %s", tc.ma, m)
+			}
+
+			network, got, err := ToAddrPort(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if network != tc.want {
+				t.Fatalf("expected network %s, got %s", tc.want, network)
+			}
+			if got != netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()) {
+				t.Fatalf("expected %s, got %s", ap, got)
+			}
+
+			// Round trips with the net.Addr conversions.
+			naddr, err := ToNetAddr(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var netAP netip.AddrPort
+			switch a := naddr.(type) {
+			case *net.TCPAddr:
+				netAP = a.AddrPort()
+			case *net.UDPAddr:
+				netAP = a.AddrPort()
+			}
+			fromNet, err := FromAddrPort(naddr.Network(), netAP)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !fromNet.Equal(m) {
+				t.Fatalf("expected %s, got %s", m, fromNet)
+			}
+		})
+	}
+}
+
+func TestAddrPortFailures(t *testing.T) {
+	if _, err := FromAddrPort("ip", netip.MustParseAddrPort("1.2.3.4:1")); err == nil {
+		t.Fatal("expected an error for an unsupported network")
+	}
+	if _, err := FromAddrPort("tcp", netip.AddrPort{}); err == nil {
+		t.Fatal("expected an error for an invalid address")
+	}
+	for _, s := range []string{
+		"/ip4/1.2.3.4",
+		"/dns/example.com/tcp/1",
+		"/ip4/1.2.3.4/sctp/1",
+		"/ip6zone/eth0/ip4/1.2.3.4/tcp/1",
+		"/tcp/1",
+	} {
+		if _, _, err := ToAddrPort(ma.StringCast(s)); err == nil {
+			t.Fatalf("expected an error for %s", s)
+		}
+	}
+
+	network, ap, err := ToAddrPort(ma.StringCast("/ip4/1.2.3.4/udp/1/quic-v1"))
+	if err != nil || network != "udp4" || ap != netip.MustParseAddrPort("1.2.3.4:1") {
+		t.Fatalf("unexpected result %s %s %v", network, ap, err)
+	}
+}
+
+func TestFromAddrDoesNotAllocateMuch(t *testing.T) {
+	addr := netip.MustParseAddr("1.2.3.4")
+	ip := net.IP(addr.AsSlice())
+	fromAddrAllocs := testing.AllocsPerRun(100, func() {
+		_, _ = FromAddr(addr)
+	})
+	fromIPAllocs := testing.AllocsPerRun(100, func() {
+		_, _ = FromIP(ip)
+	})
+	if fromAddrAllocs >= fromIPAllocs {
+		t.Fatalf("expected FromAddr to allocate less than FromIP, got %f >= %f", fromAddrAllocs, fromIPAllocs)
+	}
+	m := ma.StringCast("/ip6/::1/tcp/1")
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _, _ = ToAddrPort(m)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %f", allocs)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		prefix string
+		ma     string
+	}{
+		{"1.2.3.0/24", "/ip4/1.2.3.0/ipcidr/24"},
+		{"2001:db8::/32", "/ip6/2001:db8::/ipcidr/32"},
+		{"::ffff:1.2.3.0/120", "/ip4/1.2.3.0/ipcidr/24"},
+	} {
+		m, err := FromPrefix(netip.MustParsePrefix(tc.prefix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.String() != tc.ma {
+			t.Fatalf("expected %s, got %s", tc.ma, m)
+		}
+		p, err := ToPrefix(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ipnet, err := MultiaddrToIPNet(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.String() != ipnet.String() {
+			t.Fatalf("expected %s, got %s", ipnet, p)
+		}
+	}
+
+	if _, err := FromPrefix(netip.Prefix{}); err == nil {
+		t.Fatal("expected an error for an invalid prefix")
+	}
+	for _, s := range []string{"/ipcidr/24", "/ip4/1.2.3.0/ipcidr/128", "/ip4/1.2.3.0/tcp/1", "/ip4/1.2.3.0"} {
+		if _, err := ToPrefix(ma.StringCast(s)); err == nil {
+			t.Fatalf("expected an error for %s", s)
+		}
+	}
+}
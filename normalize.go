@@ -0,0 +1,200 @@
+package multiaddr
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// NormalizeRule rewrites a multiaddr into a canonical form. Rules must not
+// modify their input, and should return it unchanged if there is nothing to
+// rewrite.
+type NormalizeRule func(Multiaddr) (Multiaddr, error)
+
+// DefaultNormalizeRules are the rules applied by Normalize.
+//
+// Deprecated protocols without a Replacement, such as /quic (draft-29) or
+// /p2p-webrtc-direct, are left alone. /ipfs needs no rule, since it is an
+// alias of /p2p and is always printed as /p2p.
+var DefaultNormalizeRules = []NormalizeRule{
+	NormalizeReplacements,
+	NormalizeDNSCase,
+	NormalizeIPv4Mapped,
+	NormalizeEmptyHTTPPath,
+}
+
+// Normalize returns the canonical form of m according to
+// DefaultNormalizeRules. Two multiaddrs that refer to the same endpoint are
+// Equal after normalization, which makes it suitable for deduplication.
+func Normalize(m Multiaddr) (Multiaddr, error) {
+	return NormalizeWith(m, DefaultNormalizeRules...)
+}
+
+// NormalizeWith applies the given rules to m, in order.
+func NormalizeWith(m Multiaddr, rules ...NormalizeRule) (Multiaddr, error) {
+	var err error
+	for _, rule := range rules {
+		m, err = rule(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// NormalizeReplacements rewrites every component whose protocol has a
+// Replacement, e.g., /wss to /tls/ws and /https to /tls/http.
+func NormalizeReplacements(m Multiaddr) (Multiaddr, error) {
+	return expandComponents(m, func(c *Component) bool {
+		return len(c.protocol.Replacement) > 0
+	})
+}
+
+// NormalizeWSS rewrites /wss to /tls/ws.
+func NormalizeWSS(m Multiaddr) (Multiaddr, error) {
+	return expandComponents(m, func(c *Component) bool { return c.Code() == P_WSS })
+}
+
+// NormalizeHTTPS rewrites /https to /tls/http.
+func NormalizeHTTPS(m Multiaddr) (Multiaddr, error) {
+	return expandComponents(m, func(c *Component) bool { return c.Code() == P_HTTPS })
+}
+
+// NormalizeDNSCase lowercases the names in /dns, /dns4, /dns6, /dnsaddr and
+// /sni components. DNS names are case insensitive.
+func NormalizeDNSCase(m Multiaddr) (Multiaddr, error) {
+	return mapComponents(m, func(_ Multiaddr, c *Component) (Multiaddr, error) {
+		switch c.Code() {
+		case P_DNS, P_DNS4, P_DNS6, P_DNSADDR, P_SNI:
+		default:
+			return nil, nil
+		}
+		value := c.Value()
+		lower := strings.ToLower(value)
+		if lower == value {
+			return nil, nil
+		}
+		nc, err := newComponent(c.protocol, []byte(lower))
+		if err != nil {
+			return nil, err
+		}
+		return Multiaddr{*nc}, nil
+	})
+}
+
+// NormalizeIPv4Mapped rewrites IPv4-mapped IPv6 addresses, such as
+// /ip6/::ffff:1.2.3.4, to /ip4. A following /ipcidr is adjusted to match.
+// Addresses with an /ip6zone are left alone, as zones don't apply to IPv4, and
+// so are those with an /ipcidr wider than the IPv4-mapped prefix.
+func NormalizeIPv4Mapped(m Multiaddr) (Multiaddr, error) {
+	mapped := false
+	i := -1
+	return mapComponents(m, func(prev Multiaddr, c *Component) (Multiaddr, error) {
+		i++
+		switch c.Code() {
+		case P_IP6:
+			mapped = false
+			if len(prev) > 0 && prev[len(prev)-1].Code() == P_IP6ZONE {
+				return nil, nil
+			}
+			if i+1 < len(m) && m[i+1].Code() == P_IPCIDR && m[i+1].RawValue()[0] < 96 {
+				return nil, nil
+			}
+			addr, ok := netip.AddrFromSlice(c.RawValue())
+			if !ok || !addr.Is4In6() {
+				return nil, nil
+			}
+			ip4 := addr.Unmap().As4()
+			nc, err := newComponentWithCode(P_IP4, ip4[:])
+			if err != nil {
+				return nil, err
+			}
+			mapped = true
+			return Multiaddr{*nc}, nil
+		case P_IPCIDR:
+			if !mapped {
+				return nil, nil
+			}
+			mapped = false
+			nc, err := newComponent(c.protocol, []byte{c.RawValue()[0] - 96})
+			if err != nil {
+				return nil, err
+			}
+			return Multiaddr{*nc}, nil
+		default:
+			mapped = false
+			return nil, nil
+		}
+	})
+}
+
+// NormalizeEmptyHTTPPath removes /http-path components that point to the root
+// path, /http-path/%2F, as it is implied.
+func NormalizeEmptyHTTPPath(m Multiaddr) (Multiaddr, error) {
+	return mapComponents(m, func(_ Multiaddr, c *Component) (Multiaddr, error) {
+		if c.Code() != P_HTTP_PATH || string(c.RawValue()) != "/" {
+			return nil, nil
+		}
+		return Multiaddr{}, nil
+	})
+}
+
+// expandComponents replaces the components selected by f with their
+// protocol's Replacement.
+func expandComponents(m Multiaddr, f func(c *Component) bool) (Multiaddr, error) {
+	return mapComponents(m, func(_ Multiaddr, c *Component) (Multiaddr, error) {
+		if !f(c) || len(c.protocol.Replacement) == 0 {
+			return nil, nil
+		}
+		out := make(Multiaddr, 0, len(c.protocol.Replacement))
+		for _, code := range c.protocol.Replacement {
+			nc, err := newComponentWithCode(code, nil)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, *nc)
+		}
+		return out, nil
+	})
+}
+
+// mapComponents calls f on each component of m, along with the output built so
+// far. f returns the components to replace c with, or nil to keep c. m is only
+// copied once f replaces a component.
+func mapComponents(m Multiaddr, f func(prev Multiaddr, c *Component) (Multiaddr, error)) (Multiaddr, error) {
+	var out Multiaddr
+	for i := range m {
+		prev := out
+		if out == nil {
+			prev = m[:i]
+		}
+		r, err := f(prev, &m[i])
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case r != nil:
+			if out == nil {
+				out = append(make(Multiaddr, 0, len(m)+len(r)), m[:i]...)
+			}
+			out = append(out, r...)
+		case out != nil:
+			out = append(out, m[i])
+		}
+	}
+	if out == nil {
+		return m, nil
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+func newComponentWithCode(code int, value []byte) (*Component, error) {
+	p := defaultRegistry.lookupCode(code)
+	if p == nil {
+		return nil, fmt.Errorf("no protocol with code %d", code)
+	}
+	return newComponent(p, value)
+}
@@ -0,0 +1,63 @@
+package multiaddr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		out string
+	}{
+		{"/ip4/1.2.3.4/tcp/443/wss", "/ip4/1.2.3.4/tcp/443/tls/ws"},
+		{"/ip4/1.2.3.4/tcp/443/tls/sni/Example.COM/ws", "/ip4/1.2.3.4/tcp/443/tls/sni/example.com/ws"},
+		{"/dns/Example.COM/tcp/443/https", "/dns/example.com/tcp/443/tls/http"},
+		{"/dnsaddr/Bootstrap.libp2p.io/ipfs/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC", "/dnsaddr/bootstrap.libp2p.io/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC"},
+		{"/ip6/::ffff:1.2.3.4/udp/1234/quic-v1", "/ip4/1.2.3.4/udp/1234/quic-v1"},
+		{"/ip6/::ffff:1.2.3.0/ipcidr/120", "/ip4/1.2.3.0/ipcidr/24"},
+		{"/ip6/::ffff:0.0.0.0/ipcidr/64", "/ip6/::ffff:0.0.0.0/ipcidr/64"},
+		{"/ip6zone/eth0/ip6/::ffff:1.2.3.4/tcp/1", "/ip6zone/eth0/ip6/::ffff:1.2.3.4/tcp/1"},
+		{"/ip6/::1/tcp/1", "/ip6/::1/tcp/1"},
+		{"/ip4/1.2.3.4/tcp/80/http/http-path/%2F", "/ip4/1.2.3.4/tcp/80/http"},
+		{"/ip4/1.2.3.4/tcp/80/http/http-path/foo", "/ip4/1.2.3.4/tcp/80/http/http-path/foo"},
+		{"/ip4/1.2.3.4/udp/1234/quic", "/ip4/1.2.3.4/udp/1234/quic"},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			in := StringCast(tc.in)
+			inBytes := in.Bytes()
+			out, err := Normalize(in)
+			require.NoError(t, err)
+			require.Equal(t, tc.out, out.String())
+			require.Equal(t, inBytes, in.Bytes(), "input was modified")
+
+			again, err := Normalize(out)
+			require.NoError(t, err)
+			require.True(t, out.Equal(again), "not idempotent")
+		})
+	}
+}
+
+func TestNormalizeEquality(t *testing.T) {
+	a, err := Normalize(StringCast("/dns4/EXAMPLE.com/tcp/443/wss"))
+	require.NoError(t, err)
+	b, err := Normalize(StringCast("/dns4/example.com/tcp/443/tls/ws"))
+	require.NoError(t, err)
+	require.True(t, a.Equal(b))
+}
+
+func TestNormalizeWith(t *testing.T) {
+	m := StringCast("/dns/Example.com/tcp/443/wss")
+	out, err := NormalizeWith(m, NormalizeWSS)
+	require.NoError(t, err)
+	require.Equal(t, "/dns/Example.com/tcp/443/tls/ws", out.String())
+
+	out, err = NormalizeWith(m)
+	require.NoError(t, err)
+	require.True(t, m.Equal(out))
+
+	out, err = Normalize(StringCast("/http-path/%2F"))
+	require.NoError(t, err)
+	require.Empty(t, out)
+}
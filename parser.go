@@ -0,0 +1,175 @@
+package multiaddr
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// ParseOptions restricts the multiaddrs accepted by a Parser. They are meant
+// for parsing addresses from untrusted sources, such as remote peers. The zero
+// value imposes no restrictions.
+type ParseOptions struct {
+	// MaxBytes is the maximum size of the binary representation of a
+	// multiaddr. Zero means no limit. Strings of more than 16 characters
+	// per byte of MaxBytes are rejected before they are parsed.
+	MaxBytes int
+	// MaxComponents is the maximum number of components in a multiaddr. Zero
+	// means no limit.
+	MaxComponents int
+	// MaxValueLength maps protocol codes to the maximum size, in bytes, of
+	// their binary value. Protocols that are not in the map are not limited.
+	MaxValueLength map[int]int
+	// AllowedProtocols lists the only protocol codes accepted. If empty, all
+	// registered protocols are accepted.
+	AllowedProtocols []int
+	// DeniedProtocols lists protocol codes that are rejected, even if they are
+	// in AllowedProtocols.
+	DeniedProtocols []int
+	// RejectDeprecated rejects protocols that are marked as Deprecated.
+	RejectDeprecated bool
+	// Registry resolves protocol names and codes. Defaults to
+	// DefaultRegistry().
+	Registry *Registry
+}
+
+// Parser parses multiaddrs subject to a set of ParseOptions. The same rules
+// apply to both the string and the binary representation. Limits are checked
+// as the input is read, so oversized input is rejected before it is fully
+// decoded.
+//
+// A Parser is safe for concurrent use.
+type Parser struct {
+	opts    ParseOptions
+	reg     *Registry
+	allowed map[int]struct{}
+	denied  map[int]struct{}
+}
+
+// defaultParser has no limits. It backs the package level parsing functions.
+var defaultParser = &Parser{}
+
+// NewParser returns a Parser enforcing opts.
+func NewParser(opts ParseOptions) *Parser {
+	pr := &Parser{opts: opts, reg: opts.Registry}
+	if len(opts.AllowedProtocols) > 0 {
+		pr.allowed = make(map[int]struct{}, len(opts.AllowedProtocols))
+		for _, code := range opts.AllowedProtocols {
+			pr.allowed[code] = struct{}{}
+		}
+	}
+	if len(opts.DeniedProtocols) > 0 {
+		pr.denied = make(map[int]struct{}, len(opts.DeniedProtocols))
+		for _, code := range opts.DeniedProtocols {
+			pr.denied[code] = struct{}{}
+		}
+	}
+	return pr
+}
+
+// NewMultiaddr is like the package level NewMultiaddr, but enforces the
+// Parser's options.
+func (pr *Parser) NewMultiaddr(s string) (a Multiaddr, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			log.Printf("Panic in NewMultiaddr on input %q: %s", s, e)
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	b, err := pr.stringToBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	return pr.NewMultiaddrBytes(b)
+}
+
+// NewMultiaddrBytes is like the package level NewMultiaddrBytes, but enforces
+// the Parser's options.
+func (pr *Parser) NewMultiaddrBytes(b []byte) (a Multiaddr, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			log.Printf("Panic in NewMultiaddrBytes on input %q: %s", b, e)
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	bytesRead, m, err := pr.readMultiaddr(b)
+	if err != nil {
+		return nil, err
+	}
+	if bytesRead != len(b) {
+		return nil, &ParseError{
+			Offset:    bytesRead,
+			Component: len(m),
+			Err:       fmt.Errorf("%w: %v bytes leftover", ErrTrailingData, len(b)-bytesRead),
+		}
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+func (pr *Parser) registry() *Registry {
+	if pr.reg == nil {
+		return defaultRegistry
+	}
+	return pr.reg
+}
+
+func (pr *Parser) checkProtocol(p *Protocol) error {
+	if _, ok := pr.denied[p.Code]; ok {
+		return fmt.Errorf("%w: %s is denied", ErrProtocolNotAllowed, p.Name)
+	}
+	if pr.opts.RejectDeprecated && p.Deprecated {
+		return fmt.Errorf("%w: %s is deprecated", ErrProtocolNotAllowed, p.Name)
+	}
+	if pr.allowed == nil {
+		return nil
+	}
+	if _, ok := pr.allowed[p.Code]; !ok {
+		return fmt.Errorf("%w: %s is not allowed", ErrProtocolNotAllowed, p.Name)
+	}
+	return nil
+}
+
+func (pr *Parser) checkValueLength(p *Protocol, n int) error {
+	max, ok := pr.opts.MaxValueLength[p.Code]
+	if ok && n > max {
+		return fmt.Errorf("%w: %s value is %d bytes, the limit is %d", ErrLimitExceeded, p.Name, n, max)
+	}
+	return nil
+}
+
+func (pr *Parser) checkComponentCount(n int) error {
+	if pr.opts.MaxComponents > 0 && n > pr.opts.MaxComponents {
+		return fmt.Errorf("%w: more than %d components", ErrLimitExceeded, pr.opts.MaxComponents)
+	}
+	return nil
+}
+
+// maxStringExpansion bounds the characters per byte of the string values of
+// the builtin protocols. The longest are certhashes encoded in base2.
+const maxStringExpansion = 16
+
+// checkStringLength rejects strings of n characters that can't fit in
+// MaxBytes, before they are split into components.
+func (pr *Parser) checkStringLength(n int) error {
+	if pr.opts.MaxBytes <= 0 {
+		return nil
+	}
+	expansion := max(maxStringExpansion, pr.registry().tables.Load().nameExpansion)
+	if pr.opts.MaxBytes > math.MaxInt/expansion {
+		return nil
+	}
+	if limit := pr.opts.MaxBytes * expansion; n > limit {
+		return fmt.Errorf("%w: more than %d characters", ErrLimitExceeded, limit)
+	}
+	return nil
+}
+
+func (pr *Parser) checkSize(n int) error {
+	if pr.opts.MaxBytes > 0 && n > pr.opts.MaxBytes {
+		return fmt.Errorf("%w: more than %d bytes", ErrLimitExceeded, pr.opts.MaxBytes)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package multiaddr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserLimits(t *testing.T) {
+	certhash := "/certhash/uEiDDq4_xNyDorZBH3TlGazyJdOWSwvo4PUo5YHFMrvDE8g"
+	wt := "/ip4/1.2.3.4/udp/1234/quic-v1/webtransport" + certhash
+
+	for _, tc := range []struct {
+		name  string
+		opts  ParseOptions
+		addr  string
+		cause error
+	}{
+		{
+			name: "no limits",
+			addr: wt,
+		},
+		{
+			name:  "max bytes",
+			opts:  ParseOptions{MaxBytes: 20},
+			addr:  wt,
+			cause: ErrLimitExceeded,
+		},
+		{
+			name: "max bytes fits",
+			opts: ParseOptions{MaxBytes: len(StringCast(wt).Bytes())},
+			addr: wt,
+		},
+		{
+			name:  "max components",
+			opts:  ParseOptions{MaxComponents: 4},
+			addr:  wt,
+			cause: ErrLimitExceeded,
+		},
+		{
+			name: "max components fits",
+			opts: ParseOptions{MaxComponents: 5},
+			addr: wt,
+		},
+		{
+			name:  "max value length",
+			opts:  ParseOptions{MaxValueLength: map[int]int{P_CERTHASH: 16}},
+			addr:  wt,
+			cause: ErrLimitExceeded,
+		},
+		{
+			name:  "max value length repeated",
+			opts:  ParseOptions{MaxValueLength: map[int]int{P_CERTHASH: 64}, MaxComponents: 6},
+			addr:  wt + certhash + certhash,
+			cause: ErrLimitExceeded,
+		},
+		{
+			name:  "denied",
+			opts:  ParseOptions{DeniedProtocols: []int{P_WEBTRANSPORT}},
+			addr:  wt,
+			cause: ErrProtocolNotAllowed,
+		},
+		{
+			name:  "not allowed",
+			opts:  ParseOptions{AllowedProtocols: []int{P_IP4, P_IP6, P_UDP, P_QUIC_V1}},
+			addr:  wt,
+			cause: ErrProtocolNotAllowed,
+		},
+		{
+			name: "allowed",
+			opts: ParseOptions{AllowedProtocols: []int{P_IP4, P_UDP, P_QUIC_V1}},
+			addr: "/ip4/1.2.3.4/udp/1234/quic-v1",
+		},
+		{
+			name:  "deprecated",
+			opts:  ParseOptions{RejectDeprecated: true},
+			addr:  "/ip4/1.2.3.4/tcp/443/wss",
+			cause: ErrProtocolNotAllowed,
+		},
+		{
+			name: "not deprecated",
+			opts: ParseOptions{RejectDeprecated: true},
+			addr: "/ip4/1.2.3.4/tcp/443/tls/ws",
+		},
+		{
+			name:  "denied wins over allowed",
+			opts:  ParseOptions{AllowedProtocols: []int{P_IP4, P_UDP, P_QUIC_V1}, DeniedProtocols: []int{P_QUIC_V1}},
+			addr:  "/ip4/1.2.3.4/udp/1234/quic-v1",
+			cause: ErrProtocolNotAllowed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := NewParser(tc.opts)
+			fromString, strErr := pr.NewMultiaddr(tc.addr)
+			fromBytes, bytesErr := pr.NewMultiaddrBytes(StringCast(tc.addr).Bytes())
+			if tc.cause == nil {
+				require.NoError(t, strErr)
+				require.NoError(t, bytesErr)
+				require.True(t, fromString.Equal(StringCast(tc.addr)))
+				require.True(t, fromBytes.Equal(StringCast(tc.addr)))
+				return
+			}
+			require.ErrorIs(t, strErr, tc.cause)
+			require.ErrorIs(t, bytesErr, tc.cause)
+
+			var pe *ParseError
+			require.ErrorAs(t, strErr, &pe)
+			require.Equal(t, tc.addr, pe.Input)
+		})
+	}
+}
+
+func TestParserRejectsEarly(t *testing.T) {
+	pr := NewParser(ParseOptions{MaxComponents: 2})
+	_, err := pr.NewMultiaddr("/ip4/1.2.3.4/tcp/1" + strings.Repeat("/ws", 1000))
+	var pe *ParseError
+	require.ErrorAs(t, err, &pe)
+	require.Equal(t, 2, pe.Component)
+	require.Equal(t, len("/ip4/1.2.3.4/tcp/1/"), pe.Offset)
+}
+
+func TestParserRejectsLongStrings(t *testing.T) {
+	pr := NewParser(ParseOptions{MaxBytes: 64})
+	addr := "/ip4/1.2.3.4/tcp/1" + strings.Repeat("/ws", 1000)
+	_, err := pr.NewMultiaddr(addr)
+	require.ErrorIs(t, err, ErrLimitExceeded)
+	var pe *ParseError
+	require.ErrorAs(t, err, &pe)
+	require.Equal(t, -1, pe.Component)
+
+	allocs := testing.AllocsPerRun(10, func() { _, _ = pr.NewMultiaddr(addr) })
+	require.Less(t, allocs, 10.0)
+
+	// Long values within the bound are parsed.
+	_, err = pr.NewMultiaddr("/dns/" + strings.Repeat("a", 60))
+	require.NoError(t, err)
+}
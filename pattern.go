@@ -0,0 +1,59 @@
+package multiaddr
+
+import (
+	"fmt"
+
+	"github.com/multiformats/go-multiaddr/x/meg"
+)
+
+// ParsePattern parses a textual meg pattern, such as
+// "/{ip4|ip6}:ip/{tcp|udp}/*/quic-v1?/p2p*", resolving protocol names with the
+// default registry. See meg.Parse for the syntax.
+func ParsePattern(pattern string) (*meg.Expr, error) {
+	return defaultRegistry.ParsePattern(pattern)
+}
+
+// MustParsePattern is like ParsePattern, but panics on error. It is meant for
+// patterns compiled at init time, such as
+//
+//	var quicMatcher = MustParsePattern("/{ip4|ip6}:ip/udp:port/quic-v1").Matcher(nil)
+func MustParsePattern(pattern string) *meg.Expr {
+	e, err := ParsePattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// ParsePattern is like the package-level ParsePattern, but resolves protocol
+// names and aliases with r.
+func (r *Registry) ParsePattern(pattern string) (*meg.Expr, error) {
+	return meg.Parse(pattern, func(name string) (int, bool) {
+		p := r.lookupName(name)
+		if p == nil {
+			return 0, false
+		}
+		return p.Code, true
+	})
+}
+
+// MatcherText renders matcher in the notation of ParsePattern, naming codes
+// with their protocol names. See meg.Matcher.Text.
+func MatcherText(matcher meg.Matcher) (string, error) {
+	return matcher.Text(protocolName)
+}
+
+// MatcherDOT returns the state graph of matcher in the Graphviz DOT
+// language, naming codes with their protocol names. See meg.Matcher.DOT.
+func MatcherDOT(matcher meg.Matcher) string {
+	return matcher.DOT(protocolName)
+}
+
+// protocolName returns the name of the protocol with code, or the code if the
+// protocol is unknown.
+func protocolName(code int) string {
+	if p := ProtocolWithCode(code); p.Name != "" {
+		return p.Name
+	}
+	return fmt.Sprintf("protocol %d", code)
+}
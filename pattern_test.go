@@ -0,0 +1,87 @@
+package multiaddr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/multiformats/go-multiaddr/x/meg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePattern(t *testing.T) {
+	e, err := ParsePattern("/{ip4|ip6}:ip/{tcp|udp}/*/quic-v1?/p2p:peer*")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		addr  string
+		match bool
+	}{
+		{"/ip4/1.2.3.4/udp/1/quic-v1", true},
+		{"/ip6/::1/udp/1/quic-v1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC", true},
+		{"/ip4/1.2.3.4/tcp/1/tls", true},
+		{"/ip4/1.2.3.4/tcp/1/tls/ws", false},
+		{"/dns4/example.com/tcp/1/tls", false},
+	} {
+		found, err := StringCast(tc.addr).Match(e.Pattern(nil))
+		require.NoError(t, err)
+		require.Equal(t, tc.match, found, tc.addr)
+	}
+
+	var ip string
+	found, err := StringCast("/ip6/::1/udp/1/quic-v1").Match(e.Pattern(map[string]meg.CaptureFunc{
+		"ip": func(s meg.Matchable) error {
+			ip = s.Value()
+			return nil
+		},
+	}))
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "::1", ip)
+}
+
+func TestParsePatternErrors(t *testing.T) {
+	_, err := ParsePattern("/ip4/tcp/nope")
+	var synErr *meg.SyntaxError
+	require.True(t, errors.As(err, &synErr))
+	require.Equal(t, 9, synErr.Offset)
+	require.Contains(t, err.Error(), `unknown protocol "nope"`)
+}
+
+func TestRegistryParsePattern(t *testing.T) {
+	r := NewRegistry()
+	_, err := r.ParsePattern("/ip4")
+	require.Error(t, err, "the registry is empty")
+
+	p := testExperimentalProtocol
+	p.Aliases = []string{"exp"}
+	require.NoError(t, r.Add(p))
+	e, err := r.ParsePattern("/exp")
+	require.NoError(t, err)
+	m, err := r.NewMultiaddr("/experimental/a")
+	require.NoError(t, err)
+	found, err := m.Match(e.Pattern(nil))
+	require.NoError(t, err)
+	require.True(t, found)
+}
+
+func TestMatcherText(t *testing.T) {
+	const pattern = "/{ip4|ip6}:ip/{tcp|udp}/*/quic-v1?/p2p:peer*"
+	text, err := MatcherText(MustParsePattern(pattern).Matcher(nil))
+	require.NoError(t, err)
+	require.Equal(t, "/{ip4:ip|ip6:ip}/{tcp|udp}/*/quic-v1?/p2p:peer*", text)
+
+	// The text parses back to an equivalent matcher.
+	_, err = ParsePattern(text)
+	require.NoError(t, err)
+
+	text, err = MatcherText(meg.PatternToMatcher(meg.Val(P_IP4), meg.Optional(meg.Val(P_TCP))))
+	require.NoError(t, err)
+	require.Equal(t, "/ip4/tcp?", text)
+}
+
+func TestMatcherDOT(t *testing.T) {
+	dot := MatcherDOT(MustParsePattern("/ip4/{tcp|udp}").Matcher(nil))
+	for _, want := range []string{`[label="ip4"]`, `[label="tcp"]`, `[label="udp"]`} {
+		require.Contains(t, dot, want)
+	}
+}
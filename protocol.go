@@ -42,51 +42,87 @@ type Protocol struct {
 	//
 	// This should only be non-nil if Size != 0
 	Transcoder Transcoder
-}
 
-var protocolsByName = map[string]Protocol{}
-var protocolsByCode = map[int]Protocol{}
+	// Aliases are alternative names accepted when parsing. Multiaddrs are
+	// always printed using Name.
+	Aliases []string
 
-// Keep a map of pointers so that we can reuse the same pointer for the same protocol.
-var protocolPtrByCode = map[int]*Protocol{}
+	// Deprecated indicates that this protocol should not be used in new
+	// multiaddrs.
+	Deprecated bool
 
-// Protocols is the list of multiaddr protocols supported by this module.
-var Protocols = []Protocol{}
+	// Replacement lists the codes of the value-less protocols that this
+	// protocol is equivalent to, e.g., /tls/ws for /wss. It is empty if the
+	// protocol has no direct equivalent. Only protocols without an argument
+	// may have a replacement.
+	Replacement []int
 
-func AddProtocol(p Protocol) error {
-	if _, ok := protocolsByName[p.Name]; ok {
-		return fmt.Errorf("protocol by the name %q already exists", p.Name)
-	}
+	// Layer is the part of the stack this protocol belongs to.
+	Layer Layer
+}
 
-	if _, ok := protocolsByCode[p.Code]; ok {
-		return fmt.Errorf("protocol code %d already taken by %q", p.Code, p.Code)
-	}
+// Layer categorizes protocols by the part of the network stack they describe.
+type Layer int
 
-	if p.Size != 0 && p.Transcoder == nil {
-		return fmt.Errorf("protocols with arguments must define transcoders")
-	}
-	if p.Path && p.Size >= 0 {
-		return fmt.Errorf("path protocols must have variable-length sizes")
-	}
-	if len(p.VCode) == 0 {
-		return fmt.Errorf("protocol code %d is missing its VCode field", p.Code)
+const (
+	LayerUnknown Layer = iota
+	// LayerNetwork protocols locate a host, e.g., ip4 or dns.
+	LayerNetwork
+	// LayerTransport protocols carry bytes between hosts, e.g., tcp or quic-v1.
+	LayerTransport
+	// LayerSecurity protocols secure a transport, e.g., tls or noise.
+	LayerSecurity
+	// LayerMuxer protocols multiplex streams over a connection.
+	LayerMuxer
+	// LayerApplication protocols are spoken on top of a secure transport,
+	// e.g., http.
+	LayerApplication
+	// LayerPeer protocols identify or route to a peer, e.g., p2p or
+	// p2p-circuit.
+	LayerPeer
+)
+
+func (l Layer) String() string {
+	switch l {
+	case LayerNetwork:
+		return "network"
+	case LayerTransport:
+		return "transport"
+	case LayerSecurity:
+		return "security"
+	case LayerMuxer:
+		return "muxer"
+	case LayerApplication:
+		return "application"
+	case LayerPeer:
+		return "peer"
+	default:
+		return "unknown"
 	}
+}
 
-	Protocols = append(Protocols, p)
-	protocolsByName[p.Name] = p
-	protocolsByCode[p.Code] = p
-	protocolPtrByCode[p.Code] = &p
-	return nil
+// Protocols lists the protocols of the default registry. It is replaced every
+// time the default registry changes, but reading it isn't synchronized with
+// those changes.
+//
+// Deprecated: use DefaultRegistry().Protocols() instead.
+var Protocols = []Protocol{}
+
+// AddProtocol registers a protocol with the default registry.
+func AddProtocol(p Protocol) error {
+	return defaultRegistry.Add(p)
 }
 
 // ProtocolWithName returns the Protocol description with given string name.
 func ProtocolWithName(s string) Protocol {
-	return protocolsByName[s]
+	p, _ := defaultRegistry.LookupName(s)
+	return p
 }
 
 // ProtocolWithCode returns the Protocol description with given protocol code.
 func ProtocolWithCode(c int) Protocol {
-	return protocolsByCode[c]
+	p, _ := defaultRegistry.Lookup(c)
+	return p
 }
 
 // ProtocolsWithString returns a slice of protocols matching given string.
@@ -0,0 +1,370 @@
+// Code generated by internal/protocolgen from the multicodec table; DO NOT EDIT.
+
+package multiaddr
+
+const (
+	P_IP4               = 0x0004
+	P_TCP               = 0x0006
+	P_DNS               = 0x0035 // 4 or 6
+	P_DNS4              = 0x0036
+	P_DNS6              = 0x0037
+	P_DNSADDR           = 0x0038
+	P_UDP               = 0x0111
+	P_DCCP              = 0x0021
+	P_IP6               = 0x0029
+	P_IP6ZONE           = 0x002a
+	P_IPCIDR            = 0x002b
+	P_SCTP              = 0x0084
+	P_CIRCUIT           = 0x0122
+	P_ONION             = 0x01bc // also for backwards compatibility
+	P_ONION3            = 0x01bd
+	P_GARLIC64          = 0x01be
+	P_GARLIC32          = 0x01bf
+	P_UTP               = 0x012e
+	P_UDT               = 0x012d
+	P_QUIC              = 0x01cc // draft-29, use quic-v1 instead
+	P_QUIC_V1           = 0x01cd
+	P_WEBTRANSPORT      = 0x01d1
+	P_CERTHASH          = 0x01d2
+	P_HTTP              = 0x01e0
+	P_HTTP_PATH         = 0x01e1
+	P_HTTPS             = 0x01bb // deprecated alias for /tls/http
+	P_P2P               = 0x01a5
+	P_UNIX              = 0x0190
+	P_P2P_WEBRTC_DIRECT = 0x0114 // Deprecated. use webrtc-direct instead
+	P_TLS               = 0x01c0
+	P_SNI               = 0x01c1
+	P_NOISE             = 0x01c6
+	P_WS                = 0x01dd
+	P_WSS               = 0x01de // deprecated alias for /tls/ws
+	P_PLAINTEXTV2       = 0x706c61
+	P_WEBRTC_DIRECT     = 0x0118
+	P_WEBRTC            = 0x0119
+	P_MEMORY            = 0x0309
+)
+
+var (
+	protoIP4 = Protocol{
+		Name:       "ip4",
+		Code:       P_IP4,
+		VCode:      CodeToVarint(P_IP4),
+		Size:       32,
+		Transcoder: TranscoderIP4,
+		Layer:      LayerNetwork,
+	}
+	protoTCP = Protocol{
+		Name:       "tcp",
+		Code:       P_TCP,
+		VCode:      CodeToVarint(P_TCP),
+		Size:       16,
+		Transcoder: TranscoderPort,
+		Layer:      LayerTransport,
+	}
+	protoDNS = Protocol{
+		Name:       "dns",
+		Code:       P_DNS,
+		VCode:      CodeToVarint(P_DNS),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderDns,
+		Layer:      LayerNetwork,
+	}
+	protoDNS4 = Protocol{
+		Name:       "dns4",
+		Code:       P_DNS4,
+		VCode:      CodeToVarint(P_DNS4),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderDns,
+		Layer:      LayerNetwork,
+	}
+	protoDNS6 = Protocol{
+		Name:       "dns6",
+		Code:       P_DNS6,
+		VCode:      CodeToVarint(P_DNS6),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderDns,
+		Layer:      LayerNetwork,
+	}
+	protoDNSADDR = Protocol{
+		Name:       "dnsaddr",
+		Code:       P_DNSADDR,
+		VCode:      CodeToVarint(P_DNSADDR),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderDns,
+		Layer:      LayerNetwork,
+	}
+	protoUDP = Protocol{
+		Name:       "udp",
+		Code:       P_UDP,
+		VCode:      CodeToVarint(P_UDP),
+		Size:       16,
+		Transcoder: TranscoderPort,
+		Layer:      LayerTransport,
+	}
+	protoDCCP = Protocol{
+		Name:       "dccp",
+		Code:       P_DCCP,
+		VCode:      CodeToVarint(P_DCCP),
+		Size:       16,
+		Transcoder: TranscoderPort,
+		Layer:      LayerTransport,
+	}
+	protoIP6 = Protocol{
+		Name:       "ip6",
+		Code:       P_IP6,
+		VCode:      CodeToVarint(P_IP6),
+		Size:       128,
+		Transcoder: TranscoderIP6,
+		Layer:      LayerNetwork,
+	}
+	protoIP6ZONE = Protocol{
+		Name:       "ip6zone",
+		Code:       P_IP6ZONE,
+		VCode:      CodeToVarint(P_IP6ZONE),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderIP6Zone,
+		Layer:      LayerNetwork,
+	}
+	protoIPCIDR = Protocol{
+		Name:       "ipcidr",
+		Code:       P_IPCIDR,
+		VCode:      CodeToVarint(P_IPCIDR),
+		Size:       8,
+		Transcoder: TranscoderIPCIDR,
+		Layer:      LayerNetwork,
+	}
+	protoSCTP = Protocol{
+		Name:       "sctp",
+		Code:       P_SCTP,
+		VCode:      CodeToVarint(P_SCTP),
+		Size:       16,
+		Transcoder: TranscoderPort,
+		Layer:      LayerTransport,
+	}
+	protoCIRCUIT = Protocol{
+		Name:  "p2p-circuit",
+		Code:  P_CIRCUIT,
+		VCode: CodeToVarint(P_CIRCUIT),
+		Layer: LayerPeer,
+	}
+	protoONION2 = Protocol{
+		Name:       "onion",
+		Code:       P_ONION,
+		VCode:      CodeToVarint(P_ONION),
+		Size:       96,
+		Transcoder: TranscoderOnion,
+		Deprecated: true,
+		Layer:      LayerNetwork,
+	}
+	protoONION3 = Protocol{
+		Name:       "onion3",
+		Code:       P_ONION3,
+		VCode:      CodeToVarint(P_ONION3),
+		Size:       296,
+		Transcoder: TranscoderOnion3,
+		Layer:      LayerNetwork,
+	}
+	protoGARLIC64 = Protocol{
+		Name:       "garlic64",
+		Code:       P_GARLIC64,
+		VCode:      CodeToVarint(P_GARLIC64),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderGarlic64,
+		Layer:      LayerNetwork,
+	}
+	protoGARLIC32 = Protocol{
+		Name:       "garlic32",
+		Code:       P_GARLIC32,
+		VCode:      CodeToVarint(P_GARLIC32),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderGarlic32,
+		Layer:      LayerNetwork,
+	}
+	protoUTP = Protocol{
+		Name:  "utp",
+		Code:  P_UTP,
+		VCode: CodeToVarint(P_UTP),
+		Layer: LayerTransport,
+	}
+	protoUDT = Protocol{
+		Name:  "udt",
+		Code:  P_UDT,
+		VCode: CodeToVarint(P_UDT),
+		Layer: LayerTransport,
+	}
+	protoQUIC = Protocol{
+		Name:       "quic",
+		Code:       P_QUIC,
+		VCode:      CodeToVarint(P_QUIC),
+		Deprecated: true,
+		Layer:      LayerTransport,
+	}
+	protoQUICV1 = Protocol{
+		Name:  "quic-v1",
+		Code:  P_QUIC_V1,
+		VCode: CodeToVarint(P_QUIC_V1),
+		Layer: LayerTransport,
+	}
+	protoWEBTRANSPORT = Protocol{
+		Name:  "webtransport",
+		Code:  P_WEBTRANSPORT,
+		VCode: CodeToVarint(P_WEBTRANSPORT),
+		Layer: LayerTransport,
+	}
+	protoCERTHASH = Protocol{
+		Name:       "certhash",
+		Code:       P_CERTHASH,
+		VCode:      CodeToVarint(P_CERTHASH),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderCertHash,
+		Layer:      LayerSecurity,
+	}
+	protoHTTP = Protocol{
+		Name:  "http",
+		Code:  P_HTTP,
+		VCode: CodeToVarint(P_HTTP),
+		Layer: LayerApplication,
+	}
+	protoHTTPPath = Protocol{
+		Name:       "http-path",
+		Code:       P_HTTP_PATH,
+		VCode:      CodeToVarint(P_HTTP_PATH),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderHTTPPath,
+		Layer:      LayerApplication,
+	}
+	protoHTTPS = Protocol{
+		Name:        "https",
+		Code:        P_HTTPS,
+		VCode:       CodeToVarint(P_HTTPS),
+		Deprecated:  true,
+		Replacement: []int{P_TLS, P_HTTP},
+		Layer:       LayerApplication,
+	}
+	protoP2P = Protocol{
+		Name:       "p2p",
+		Code:       P_P2P,
+		VCode:      CodeToVarint(P_P2P),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderP2P,
+		Aliases:    []string{"ipfs"},
+		Layer:      LayerPeer,
+	}
+	protoUNIX = Protocol{
+		Name:       "unix",
+		Code:       P_UNIX,
+		VCode:      CodeToVarint(P_UNIX),
+		Size:       LengthPrefixedVarSize,
+		Path:       true,
+		Transcoder: TranscoderUnix,
+		Layer:      LayerNetwork,
+	}
+	protoP2P_WEBRTC_DIRECT = Protocol{
+		Name:       "p2p-webrtc-direct",
+		Code:       P_P2P_WEBRTC_DIRECT,
+		VCode:      CodeToVarint(P_P2P_WEBRTC_DIRECT),
+		Deprecated: true,
+		Layer:      LayerTransport,
+	}
+	protoTLS = Protocol{
+		Name:  "tls",
+		Code:  P_TLS,
+		VCode: CodeToVarint(P_TLS),
+		Layer: LayerSecurity,
+	}
+	protoSNI = Protocol{
+		Name:       "sni",
+		Code:       P_SNI,
+		VCode:      CodeToVarint(P_SNI),
+		Size:       LengthPrefixedVarSize,
+		Transcoder: TranscoderDns,
+		Layer:      LayerSecurity,
+	}
+	protoNOISE = Protocol{
+		Name:  "noise",
+		Code:  P_NOISE,
+		VCode: CodeToVarint(P_NOISE),
+		Layer: LayerSecurity,
+	}
+	protoWS = Protocol{
+		Name:  "ws",
+		Code:  P_WS,
+		VCode: CodeToVarint(P_WS),
+		Layer: LayerTransport,
+	}
+	protoWSS = Protocol{
+		Name:        "wss",
+		Code:        P_WSS,
+		VCode:       CodeToVarint(P_WSS),
+		Deprecated:  true,
+		Replacement: []int{P_TLS, P_WS},
+		Layer:       LayerTransport,
+	}
+	protoPlaintextV2 = Protocol{
+		Name:  "plaintextv2",
+		Code:  P_PLAINTEXTV2,
+		VCode: CodeToVarint(P_PLAINTEXTV2),
+		Layer: LayerSecurity,
+	}
+	protoWebRTCDirect = Protocol{
+		Name:  "webrtc-direct",
+		Code:  P_WEBRTC_DIRECT,
+		VCode: CodeToVarint(P_WEBRTC_DIRECT),
+		Layer: LayerTransport,
+	}
+	protoWebRTC = Protocol{
+		Name:  "webrtc",
+		Code:  P_WEBRTC,
+		VCode: CodeToVarint(P_WEBRTC),
+		Layer: LayerTransport,
+	}
+	protoMemory = Protocol{
+		Name:       "memory",
+		Code:       P_MEMORY,
+		VCode:      CodeToVarint(P_MEMORY),
+		Size:       64,
+		Transcoder: TranscoderMemory,
+		Layer:      LayerTransport,
+	}
+)
+
+// builtinProtocols are registered with the default registry, in order.
+var builtinProtocols = []Protocol{
+	protoIP4,
+	protoTCP,
+	protoDNS,
+	protoDNS4,
+	protoDNS6,
+	protoDNSADDR,
+	protoUDP,
+	protoDCCP,
+	protoIP6,
+	protoIP6ZONE,
+	protoIPCIDR,
+	protoSCTP,
+	protoCIRCUIT,
+	protoONION2,
+	protoONION3,
+	protoGARLIC64,
+	protoGARLIC32,
+	protoUTP,
+	protoUDT,
+	protoQUIC,
+	protoQUICV1,
+	protoWEBTRANSPORT,
+	protoCERTHASH,
+	protoHTTP,
+	protoHTTPPath,
+	protoHTTPS,
+	protoP2P,
+	protoUNIX,
+	protoP2P_WEBRTC_DIRECT,
+	protoTLS,
+	protoSNI,
+	protoNOISE,
+	protoWS,
+	protoWSS,
+	protoPlaintextV2,
+	protoWebRTCDirect,
+	protoWebRTC,
+	protoMemory,
+}
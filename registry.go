@@ -0,0 +1,204 @@
+package multiaddr
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds the set of protocols used to parse multiaddrs. The package
+// level functions, such as NewMultiaddr and AddProtocol, use the default
+// registry returned by DefaultRegistry. Separate registries let tests and
+// plugins work with experimental protocols without affecting the rest of the
+// program.
+//
+// A Registry is safe for concurrent use. Lookups never block, even while
+// protocols are being added or removed.
+//
+// Components keep a reference to the protocol they were parsed with, so
+// formatting a multiaddr does not depend on the registry it came from.
+type Registry struct {
+	// mu serializes writers. Readers load tables without locking.
+	mu     sync.Mutex
+	tables atomic.Pointer[registryTables]
+	parser *Parser
+}
+
+// registryTables is an immutable snapshot of a Registry's protocols.
+type registryTables struct {
+	byName map[string]*Protocol
+	byCode map[int]*Protocol
+	// protocols are in registration order.
+	protocols []*Protocol
+	// nameExpansion is the most characters a protocol name takes per byte
+	// of its code, counting the slash. It is not lowered by Remove.
+	nameExpansion int
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry used by the package level functions.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// NewRegistry returns an empty registry. Use DefaultRegistry().Clone() to start
+// from the protocols supported by this module instead.
+func NewRegistry() *Registry {
+	r := &Registry{}
+	r.tables.Store(&registryTables{
+		byName: map[string]*Protocol{},
+		byCode: map[int]*Protocol{},
+	})
+	r.parser = &Parser{reg: r}
+	return r
+}
+
+// Clone returns a new registry with the same protocols as r.
+func (r *Registry) Clone() *Registry {
+	c := NewRegistry()
+	c.tables.Store(r.tables.Load().clone())
+	return c
+}
+
+func (t *registryTables) clone() *registryTables {
+	return &registryTables{
+		byName:    maps.Clone(t.byName),
+		byCode:    maps.Clone(t.byCode),
+		protocols: slices.Clone(t.protocols),
+
+		nameExpansion: t.nameExpansion,
+	}
+}
+
+// update applies f to a copy of the current tables, and publishes the copy if f
+// succeeds.
+func (r *Registry) update(f func(t *registryTables) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.tables.Load().clone()
+	if err := f(t); err != nil {
+		return err
+	}
+	r.tables.Store(t)
+	if r == defaultRegistry {
+		Protocols = r.Protocols()
+	}
+	return nil
+}
+
+// Add registers a new protocol, along with its aliases.
+func (r *Registry) Add(p Protocol) error {
+	if p.Size != 0 && p.Transcoder == nil {
+		return fmt.Errorf("protocols with arguments must define transcoders")
+	}
+	if p.Path && p.Size >= 0 {
+		return fmt.Errorf("path protocols must have variable-length sizes")
+	}
+	if len(p.VCode) == 0 {
+		return fmt.Errorf("protocol code %d is missing its VCode field", p.Code)
+	}
+	if len(p.Replacement) > 0 && p.Size != 0 {
+		return fmt.Errorf("protocol %q takes an argument and can't have a replacement", p.Name)
+	}
+	if slices.Contains(p.Replacement, p.Code) {
+		return fmt.Errorf("protocol %q can't be its own replacement", p.Name)
+	}
+	p.Aliases = slices.Clone(p.Aliases)
+	p.Replacement = slices.Clone(p.Replacement)
+
+	return r.update(func(t *registryTables) error {
+		for _, name := range append([]string{p.Name}, p.Aliases...) {
+			if _, ok := t.byName[name]; ok {
+				return fmt.Errorf("protocol by the name %q already exists", name)
+			}
+		}
+		if existing, ok := t.byCode[p.Code]; ok {
+			return fmt.Errorf("protocol code %d already taken by %q", p.Code, existing.Name)
+		}
+		t.protocols = append(t.protocols, &p)
+		t.byName[p.Name] = &p
+		for _, alias := range p.Aliases {
+			t.byName[alias] = &p
+		}
+		t.byCode[p.Code] = &p
+		for _, name := range append([]string{p.Name}, p.Aliases...) {
+			t.nameExpansion = max(t.nameExpansion, (len(name)+len(p.VCode))/len(p.VCode))
+		}
+		return nil
+	})
+}
+
+// Remove unregisters the protocol with the given code, along with all of its
+// names. Multiaddrs that were already parsed are not affected.
+func (r *Registry) Remove(code int) error {
+	return r.update(func(t *registryTables) error {
+		p, ok := t.byCode[code]
+		if !ok {
+			return fmt.Errorf("no protocol with code %d", code)
+		}
+		delete(t.byCode, code)
+		maps.DeleteFunc(t.byName, func(_ string, v *Protocol) bool { return v == p })
+		t.protocols = slices.DeleteFunc(t.protocols, func(v *Protocol) bool { return v == p })
+		return nil
+	})
+}
+
+// Lookup returns the protocol with the given code.
+func (r *Registry) Lookup(code int) (Protocol, bool) {
+	p := r.lookupCode(code)
+	if p == nil {
+		return Protocol{}, false
+	}
+	return *p, true
+}
+
+// LookupName returns the protocol with the given name or alias.
+func (r *Registry) LookupName(name string) (Protocol, bool) {
+	p := r.lookupName(name)
+	if p == nil {
+		return Protocol{}, false
+	}
+	return *p, true
+}
+
+func (r *Registry) lookupCode(code int) *Protocol {
+	return r.tables.Load().byCode[code]
+}
+
+func (r *Registry) lookupName(name string) *Protocol {
+	return r.tables.Load().byName[name]
+}
+
+// Protocols returns the registered protocols in registration order.
+func (r *Registry) Protocols() []Protocol {
+	t := r.tables.Load()
+	out := make([]Protocol, len(t.protocols))
+	for i, p := range t.protocols {
+		out[i] = *p
+	}
+	return out
+}
+
+// NewMultiaddr parses a multiaddr string using the protocols in r.
+func (r *Registry) NewMultiaddr(s string) (Multiaddr, error) {
+	return r.parser.NewMultiaddr(s)
+}
+
+// NewMultiaddrBytes parses a binary multiaddr using the protocols in r.
+func (r *Registry) NewMultiaddrBytes(b []byte) (Multiaddr, error) {
+	return r.parser.NewMultiaddrBytes(b)
+}
+
+// NewMultiaddrView returns a view over b that decodes its components using the
+// protocols in r.
+func (r *Registry) NewMultiaddrView(b []byte) MultiaddrView {
+	return MultiaddrView{b: b, registry: r}
+}
+
+// NewComponent constructs a component using the protocols in r.
+func (r *Registry) NewComponent(protocol, value string) (*Component, error) {
+	return newComponentFromString(r, protocol, value)
+}
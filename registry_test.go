@@ -0,0 +1,183 @@
+package multiaddr
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testExperimentalCode = 0x300001
+
+var testExperimentalProtocol = Protocol{
+	Name:       "experimental",
+	Code:       testExperimentalCode,
+	VCode:      CodeToVarint(testExperimentalCode),
+	Size:       LengthPrefixedVarSize,
+	Transcoder: TranscoderUnix,
+	Path:       true,
+}
+
+func TestRegistryIsolated(t *testing.T) {
+	r := DefaultRegistry().Clone()
+	require.NoError(t, r.Add(testExperimentalProtocol))
+
+	m, err := r.NewMultiaddr("/ip4/1.2.3.4/experimental/a/b")
+	require.NoError(t, err)
+	require.Equal(t, "/ip4/1.2.3.4/experimental/a/b", m.String())
+
+	m2, err := r.NewMultiaddrBytes(m.Bytes())
+	require.NoError(t, err)
+	require.True(t, m.Equal(m2))
+	require.NoError(t, r.NewMultiaddrView(m.Bytes()).Validate())
+	m2, err = r.NewMultiaddrView(m.Bytes()).ToMultiaddr()
+	require.NoError(t, err)
+	require.True(t, m.Equal(m2))
+
+	c, err := r.NewComponent("experimental", "/x")
+	require.NoError(t, err)
+	require.Equal(t, testExperimentalCode, c.Code())
+
+	// The default registry doesn't know about it.
+	_, ok := DefaultRegistry().Lookup(testExperimentalCode)
+	require.False(t, ok)
+	_, err = NewMultiaddr("/ip4/1.2.3.4/experimental/a/b")
+	require.ErrorIs(t, err, ErrUnknownProtocol)
+	_, err = NewMultiaddrBytes(m.Bytes())
+	require.ErrorIs(t, err, ErrUnknownProtocol)
+	require.ErrorIs(t, NewMultiaddrView(m.Bytes()).Validate(), ErrUnknownProtocol)
+	_, err = NewMultiaddrView(m.Bytes()).ToMultiaddr()
+	require.ErrorIs(t, err, ErrUnknownProtocol)
+
+	// Parsed multiaddrs survive removing the protocol.
+	require.NoError(t, r.Remove(testExperimentalCode))
+	require.Error(t, r.Remove(testExperimentalCode))
+	_, ok = r.LookupName("experimental")
+	require.False(t, ok)
+	_, err = r.NewMultiaddr("/ip4/1.2.3.4/experimental/a/b")
+	require.ErrorIs(t, err, ErrUnknownProtocol)
+	require.Equal(t, "/ip4/1.2.3.4/experimental/a/b", m.String())
+}
+
+func TestRegistryAdd(t *testing.T) {
+	r := NewRegistry()
+	require.Empty(t, r.Protocols())
+	require.NoError(t, r.Add(protoIP4))
+	require.Error(t, r.Add(protoIP4))
+
+	dupName := protoTCP
+	dupName.Name = "ip4"
+	require.Error(t, r.Add(dupName))
+
+	dupCode := protoTCP
+	dupCode.Code = P_IP4
+	require.Error(t, r.Add(dupCode))
+
+	noTranscoder := testExperimentalProtocol
+	noTranscoder.Transcoder = nil
+	require.Error(t, r.Add(noTranscoder))
+
+	require.Equal(t, []string{"ip4"}, protocolNames(r.Protocols()))
+
+	// Only ip4 is known.
+	_, err := r.NewMultiaddr("/ip4/1.2.3.4/tcp/1")
+	require.ErrorIs(t, err, ErrUnknownProtocol)
+}
+
+func TestRegistryAlias(t *testing.T) {
+	p, ok := DefaultRegistry().LookupName("ipfs")
+	require.True(t, ok)
+	require.Equal(t, "p2p", p.Name)
+
+	r := DefaultRegistry().Clone()
+	require.NoError(t, r.Remove(P_P2P))
+	_, ok = r.LookupName("ipfs")
+	require.False(t, ok)
+}
+
+func TestRegistryAddAliases(t *testing.T) {
+	r := DefaultRegistry().Clone()
+	p := testExperimentalProtocol
+	p.Aliases = []string{"exp"}
+	require.NoError(t, r.Add(p))
+
+	m, err := r.NewMultiaddr("/exp/a")
+	require.NoError(t, err)
+	require.Equal(t, "/experimental/a", m.String())
+
+	// Aliases can't shadow existing names.
+	p2 := testExperimentalProtocol
+	p2.Name, p2.Code, p2.VCode = "experimental2", testExperimentalCode+1, CodeToVarint(testExperimentalCode+1)
+	p2.Aliases = []string{"ipfs"}
+	require.Error(t, r.Add(p2))
+	_, ok := r.Lookup(p2.Code)
+	require.False(t, ok)
+
+	withReplacement := testExperimentalProtocol
+	withReplacement.Replacement = []int{P_TLS}
+	require.Error(t, NewRegistry().Add(withReplacement))
+}
+
+func TestProtocolMetadata(t *testing.T) {
+	for _, p := range DefaultRegistry().Protocols() {
+		require.NotEqual(t, LayerUnknown, p.Layer, p.Name)
+		for _, code := range p.Replacement {
+			_, ok := DefaultRegistry().Lookup(code)
+			require.True(t, ok, "%s replacement %d", p.Name, code)
+		}
+		if len(p.Replacement) > 0 {
+			require.True(t, p.Deprecated, p.Name)
+		}
+	}
+	require.Equal(t, []int{P_TLS, P_WS}, ProtocolWithCode(P_WSS).Replacement)
+	require.Equal(t, []string{"ipfs"}, ProtocolWithCode(P_P2P).Aliases)
+	require.Equal(t, "security", ProtocolWithCode(P_TLS).Layer.String())
+}
+
+func TestRegistryConcurrentUse(t *testing.T) {
+	r := DefaultRegistry().Clone()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = r.Add(testExperimentalProtocol)
+			_ = r.Remove(testExperimentalCode)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_, err := r.NewMultiaddr("/ip4/1.2.3.4/tcp/1")
+			require.NoError(t, err)
+		}
+	}()
+	wg.Wait()
+}
+
+func protocolNames(ps []Protocol) []string {
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func TestProtocolsGlobalFollowsDefaultRegistry(t *testing.T) {
+	codes := func(ps []Protocol) []int {
+		var out []int
+		for _, p := range ps {
+			out = append(out, p.Code)
+		}
+		return out
+	}
+	require.Equal(t, codes(DefaultRegistry().Protocols()), codes(Protocols))
+
+	require.NoError(t, AddProtocol(testExperimentalProtocol))
+	require.Equal(t, codes(DefaultRegistry().Protocols()), codes(Protocols))
+	require.Contains(t, codes(Protocols), testExperimentalCode)
+
+	require.NoError(t, DefaultRegistry().Remove(testExperimentalCode))
+	require.Equal(t, codes(DefaultRegistry().Protocols()), codes(Protocols))
+	require.NotContains(t, codes(Protocols), testExperimentalCode)
+}
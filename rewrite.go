@@ -0,0 +1,201 @@
+package multiaddr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/multiformats/go-multiaddr/x/meg"
+)
+
+// Rewriter rewrites the multiaddrs that match a pattern according to a
+// template. A Rewriter is safe for concurrent use.
+type Rewriter struct {
+	matcher  meg.Matcher
+	template []templatePart
+}
+
+// templatePart is one of: literal components, the components captured under
+// ref, or a component of protocol proto whose value is the value captured
+// under ref.
+type templatePart struct {
+	literal Multiaddr
+	ref     string
+	proto   *Protocol
+}
+
+// NewRewriter returns a Rewriter for a textual pattern (see ParsePattern) and
+// a template. The template is a multiaddr string in which "$name" stands for
+// the components captured under name:
+//
+//   - as a protocol, "$name" inserts all the captured components, in order.
+//   - as a value, "$name" uses the value of the single captured component.
+//
+// For example, the following rewriter replaces /wss with /tls/ws:
+//
+//	NewRewriter("/*:addr*/wss/*:rest*", "/$addr/tls/ws/$rest")
+//
+// and this one swaps the IP address, keeping the rest of the address:
+//
+//	NewRewriter("/{ip4|ip6}/*:rest*", "/ip4/1.2.3.4/$rest")
+func NewRewriter(pattern, template string) (*Rewriter, error) {
+	return defaultRegistry.NewRewriter(pattern, template)
+}
+
+// NewRewriter is like the package-level NewRewriter, but uses r to resolve
+// protocol names.
+func (r *Registry) NewRewriter(pattern, template string) (*Rewriter, error) {
+	expr, err := r.ParsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := r.parseTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	names := expr.Names()
+	for _, p := range parts {
+		if p.ref != "" && !slices.Contains(names, p.ref) {
+			return nil, fmt.Errorf("template %q refers to unknown capture %q", template, p.ref)
+		}
+	}
+	return &Rewriter{matcher: expr.Matcher(nil), template: parts}, nil
+}
+
+func (r *Registry) parseTemplate(template string) ([]templatePart, error) {
+	if template != "" && template[0] != '/' {
+		return nil, fmt.Errorf("template %q must begin with /", template)
+	}
+
+	var parts []templatePart
+	var literal strings.Builder
+	flush := func() error {
+		if literal.Len() == 0 {
+			return nil
+		}
+		m, err := r.NewMultiaddr(literal.String())
+		if err != nil {
+			return err
+		}
+		literal.Reset()
+		parts = append(parts, templatePart{literal: m})
+		return nil
+	}
+
+	segments := strings.Split(template, "/")[1:]
+	for i := 0; i < len(segments); i++ {
+		seg := segments[i]
+		if seg == "" && i == len(segments)-1 {
+			// Trailing slash.
+			break
+		}
+		if name, ok := strings.CutPrefix(seg, "$"); ok {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			parts = append(parts, templatePart{ref: name})
+			continue
+		}
+
+		p := r.lookupName(seg)
+		if p == nil || p.Size == 0 {
+			// Let the parser report unknown protocols.
+			literal.WriteString("/" + seg)
+			continue
+		}
+		if i+1 == len(segments) {
+			return nil, fmt.Errorf("template %q is missing the value of /%s", template, seg)
+		}
+		value := segments[i+1]
+		if p.Path {
+			value = strings.Join(segments[i+1:], "/")
+		}
+		if name, ok := strings.CutPrefix(value, "$"); ok && !strings.Contains(name, "/") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			parts = append(parts, templatePart{ref: name, proto: p})
+		} else {
+			literal.WriteString("/" + seg + "/" + value)
+		}
+		if p.Path {
+			break
+		}
+		i++
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// Rewrite returns the rewritten multiaddr if m matches the pattern, and
+// reports whether it did. Otherwise it returns m. Components inserted from
+// captures share their memory with m. Rewrites that produce an invalid
+// multiaddr, such as one with components after a path protocol like /unix,
+// return an error wrapping ErrInvalidComposition.
+func (rw *Rewriter) Rewrite(m Multiaddr) (Multiaddr, bool, error) {
+	caps, ok, err := m.MatchCaptures(rw.matcher)
+	if err != nil || !ok {
+		return m, false, err
+	}
+
+	out := make(Multiaddr, 0, len(m))
+	for _, p := range rw.template {
+		switch {
+		case p.literal != nil:
+			out = append(out, p.literal...)
+		case p.proto == nil:
+			for _, c := range caps[p.ref] {
+				out = append(out, *c.(*Component))
+			}
+		default:
+			vs := caps[p.ref]
+			if len(vs) != 1 {
+				return m, false, fmt.Errorf("capture %q used as the value of /%s has %d components, expected 1", p.ref, p.proto.Name, len(vs))
+			}
+			var bts []byte
+			if p.proto.Transcoder != nil {
+				bts, err = p.proto.Transcoder.StringToBytes(vs[0].Value())
+				if err != nil {
+					return m, false, fmt.Errorf("failed to use capture %q as the value of /%s: %w", p.ref, p.proto.Name, err)
+				}
+			}
+			c, err := newComponent(p.proto, bts)
+			if err != nil {
+				return m, false, err
+			}
+			out = append(out, *c)
+		}
+	}
+	if err := checkRewritten(out); err != nil {
+		return m, false, err
+	}
+	return out, true, nil
+}
+
+// checkRewritten checks that a rewritten multiaddr can be written as a string
+// and parsed back. A path protocol consumes the rest of the string, so it must
+// be the last component.
+func checkRewritten(m Multiaddr) error {
+	for i := range len(m) - 1 {
+		if p := m[i].Protocol(); p.Path {
+			return fmt.Errorf("%w: /%s can't follow the path protocol /%s", ErrInvalidComposition, m[i+1].Protocol().Name, p.Name)
+		}
+	}
+	return nil
+}
+
+// RewriteAll rewrites the multiaddrs in addrs that match the pattern. The
+// other multiaddrs are returned unchanged, and the order is preserved.
+func (rw *Rewriter) RewriteAll(addrs []Multiaddr) ([]Multiaddr, error) {
+	out := make([]Multiaddr, len(addrs))
+	for i, a := range addrs {
+		m, _, err := rw.Rewrite(a)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = m
+	}
+	return out, nil
+}
@@ -0,0 +1,142 @@
+package multiaddr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriter(t *testing.T) {
+	const peer = "QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC"
+	for _, tc := range []struct {
+		name     string
+		pattern  string
+		template string
+		in       string
+		out      string
+		ok       bool
+	}{
+		{
+			name:     "replace wss",
+			pattern:  "/*:addr*/wss/*:rest*",
+			template: "/$addr/tls/ws/$rest",
+			in:       "/dns/example.com/tcp/443/wss/p2p/" + peer,
+			out:      "/dns/example.com/tcp/443/tls/ws/p2p/" + peer,
+			ok:       true,
+		},
+		{
+			name:     "strip p2p",
+			pattern:  "/*:addr*/p2p",
+			template: "/$addr",
+			in:       "/ip4/1.2.3.4/tcp/1/p2p/" + peer,
+			out:      "/ip4/1.2.3.4/tcp/1",
+			ok:       true,
+		},
+		{
+			name:     "swap ip",
+			pattern:  "/{ip4|ip6}/*:rest*",
+			template: "/ip4/5.6.7.8/$rest",
+			in:       "/ip6/::1/udp/1/quic-v1",
+			out:      "/ip4/5.6.7.8/udp/1/quic-v1",
+			ok:       true,
+		},
+		{
+			name:     "insert certhash",
+			pattern:  "/*:addr*/webtransport",
+			template: "/$addr/webtransport/certhash/uEiDDq4_xNyDorZBH3TlGazyJdOWSwvo4PUo5YHFMrvDE8g",
+			in:       "/ip4/1.2.3.4/udp/1/quic-v1/webtransport",
+			out:      "/ip4/1.2.3.4/udp/1/quic-v1/webtransport/certhash/uEiDDq4_xNyDorZBH3TlGazyJdOWSwvo4PUo5YHFMrvDE8g",
+			ok:       true,
+		},
+		{
+			name:     "value from another protocol",
+			pattern:  "/dns:host/tcp:port/tls/ws",
+			template: "/dns/$host/tcp/$port/tls/sni/$host/ws",
+			in:       "/dns/example.com/tcp/443/tls/ws",
+			out:      "/dns/example.com/tcp/443/tls/sni/example.com/ws",
+			ok:       true,
+		},
+		{
+			name:     "path value",
+			pattern:  "/unix:path",
+			template: "/ip4/1.2.3.4/unix/$path",
+			in:       "/unix/tmp/sock",
+			out:      "/ip4/1.2.3.4/unix/tmp/sock",
+			ok:       true,
+		},
+		{
+			name:     "no match",
+			pattern:  "/*:addr*/wss",
+			template: "/$addr/tls/ws",
+			in:       "/ip4/1.2.3.4/tcp/1",
+			out:      "/ip4/1.2.3.4/tcp/1",
+			ok:       false,
+		},
+		{
+			name:     "empty capture",
+			pattern:  "/ip4/tcp/p2p:peer?",
+			template: "/$peer",
+			in:       "/ip4/1.2.3.4/tcp/1",
+			out:      "",
+			ok:       true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rw, err := NewRewriter(tc.pattern, tc.template)
+			require.NoError(t, err)
+			out, ok, err := rw.Rewrite(StringCast(tc.in))
+			require.NoError(t, err)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.out, out.String())
+		})
+	}
+}
+
+func TestRewriteAll(t *testing.T) {
+	rw, err := NewRewriter("/*:addr*/p2p", "/$addr")
+	require.NoError(t, err)
+	in := []Multiaddr{
+		StringCast("/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC"),
+		StringCast("/ip4/1.2.3.4/tcp/2"),
+	}
+	out, err := rw.RewriteAll(in)
+	require.NoError(t, err)
+	require.Equal(t, []Multiaddr{StringCast("/ip4/1.2.3.4/tcp/1"), in[1]}, out)
+	require.Equal(t, "/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC", in[0].String(), "input was modified")
+}
+
+func TestRewriterErrors(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, template string
+	}{
+		{"/{ip4", "/ip4/1.2.3.4"},
+		{"/ip4", "ip4/1.2.3.4"},
+		{"/ip4", "/ip4/nope"},
+		{"/ip4", "/nope"},
+		{"/ip4", "/tcp"},
+		{"/ip4:ip", "/$other"},
+		{"/ip4:ip", "/dns/$other"},
+	} {
+		_, err := NewRewriter(tc.pattern, tc.template)
+		require.Error(t, err, "%s -> %s", tc.pattern, tc.template)
+	}
+
+	rw, err := NewRewriter("/tcp:ports+", "/udp/$ports")
+	require.NoError(t, err)
+	_, ok, err := rw.Rewrite(StringCast("/tcp/1/tcp/2"))
+	require.Error(t, err, "more than one value")
+	require.False(t, ok)
+
+	rw, err = NewRewriter("/dns:host", "/ip4/$host")
+	require.NoError(t, err)
+	_, _, err = rw.Rewrite(StringCast("/dns/example.com"))
+	require.Error(t, err, "not an IP address")
+
+	rw, err = NewRewriter("/ip4:ip/unix:path", "/$path/$ip")
+	require.NoError(t, err)
+	m := StringCast("/ip4/1.2.3.4/unix/a")
+	out, ok, err := rw.Rewrite(m)
+	require.ErrorIs(t, err, ErrInvalidComposition)
+	require.False(t, ok)
+	require.True(t, out.Equal(m))
+}
@@ -0,0 +1,180 @@
+package multiaddr
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+// MultiaddrSet is a set of multiaddrs. Membership is based on the binary
+// representation, like Multiaddr.Equal, and takes constant time.
+//
+// The zero value is an empty set ready to use. A MultiaddrSet is not safe for
+// concurrent use.
+type MultiaddrSet struct {
+	m map[string]Multiaddr
+}
+
+// NewMultiaddrSet returns a set containing addrs.
+func NewMultiaddrSet(addrs ...Multiaddr) *MultiaddrSet {
+	s := &MultiaddrSet{m: make(map[string]Multiaddr, len(addrs))}
+	s.Add(addrs...)
+	return s
+}
+
+// setKey returns the binary representation of m as a string. It doesn't
+// allocate for multiaddrs with a single component.
+func setKey(m Multiaddr) string {
+	switch len(m) {
+	case 0:
+		return ""
+	case 1:
+		return m[0].bytes
+	}
+	size := 0
+	for _, c := range m {
+		size += len(c.bytes)
+	}
+	var b strings.Builder
+	b.Grow(size)
+	for _, c := range m {
+		b.WriteString(c.bytes)
+	}
+	return b.String()
+}
+
+// appendSetKey appends the binary representation of m to b. Map lookups with
+// string(appendSetKey(buf[:0], m)) don't allocate.
+func appendSetKey(b []byte, m Multiaddr) []byte {
+	for _, c := range m {
+		b = append(b, c.bytes...)
+	}
+	return b
+}
+
+// Add adds addrs to the set. The set keeps copies of addrs, so the caller may
+// modify them afterwards.
+func (s *MultiaddrSet) Add(addrs ...Multiaddr) {
+	if s.m == nil {
+		s.m = make(map[string]Multiaddr, len(addrs))
+	}
+	for _, a := range addrs {
+		s.m[setKey(a)] = a.copy()
+	}
+}
+
+// Remove removes addr from the set, and reports whether it was present.
+func (s *MultiaddrSet) Remove(addr Multiaddr) bool {
+	if s == nil {
+		return false
+	}
+	var buf [64]byte
+	k := appendSetKey(buf[:0], addr)
+	_, ok := s.m[string(k)]
+	delete(s.m, string(k))
+	return ok
+}
+
+// Has reports whether addr is in the set. It can be passed to FilterAddrs to
+// keep only the addresses in the set.
+func (s *MultiaddrSet) Has(addr Multiaddr) bool {
+	if s == nil {
+		return false
+	}
+	var buf [64]byte
+	_, ok := s.m[string(appendSetKey(buf[:0], addr))]
+	return ok
+}
+
+// Len returns the number of multiaddrs in the set.
+func (s *MultiaddrSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.m)
+}
+
+// Clone returns a copy of the set.
+func (s *MultiaddrSet) Clone() *MultiaddrSet {
+	out := &MultiaddrSet{m: make(map[string]Multiaddr, s.Len())}
+	if s != nil {
+		for k, a := range s.m {
+			out.m[k] = a
+		}
+	}
+	return out
+}
+
+// Union returns a new set with the multiaddrs that are in s or o.
+func (s *MultiaddrSet) Union(o *MultiaddrSet) *MultiaddrSet {
+	out := s.Clone()
+	if o != nil {
+		for k, a := range o.m {
+			out.m[k] = a
+		}
+	}
+	return out
+}
+
+// Intersect returns a new set with the multiaddrs that are in both s and o.
+func (s *MultiaddrSet) Intersect(o *MultiaddrSet) *MultiaddrSet {
+	small, large := s, o
+	if small.Len() > large.Len() {
+		small, large = large, small
+	}
+	out := &MultiaddrSet{m: make(map[string]Multiaddr)}
+	if small == nil || large == nil {
+		return out
+	}
+	for k, a := range small.m {
+		if _, ok := large.m[k]; ok {
+			out.m[k] = a
+		}
+	}
+	return out
+}
+
+// Difference returns a new set with the multiaddrs that are in s but not in o.
+func (s *MultiaddrSet) Difference(o *MultiaddrSet) *MultiaddrSet {
+	out := &MultiaddrSet{m: make(map[string]Multiaddr)}
+	if s == nil {
+		return out
+	}
+	for k, a := range s.m {
+		if o != nil {
+			if _, ok := o.m[k]; ok {
+				continue
+			}
+		}
+		out.m[k] = a
+	}
+	return out
+}
+
+// All returns an iterator over the multiaddrs in the set, in no particular
+// order.
+func (s *MultiaddrSet) All() iter.Seq[Multiaddr] {
+	return func(yield func(Multiaddr) bool) {
+		if s == nil {
+			return
+		}
+		for _, a := range s.m {
+			if !yield(a) {
+				return
+			}
+		}
+	}
+}
+
+// Sorted returns the multiaddrs in the set, sorted by Multiaddr.Compare.
+func (s *MultiaddrSet) Sorted() []Multiaddr {
+	if s.Len() == 0 {
+		return nil
+	}
+	out := make([]Multiaddr, 0, len(s.m))
+	for _, a := range s.m {
+		out = append(out, a)
+	}
+	slices.SortFunc(out, func(a, b Multiaddr) int { return a.Compare(b) })
+	return out
+}
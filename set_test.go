@@ -0,0 +1,104 @@
+package multiaddr
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setStrings(s *MultiaddrSet) []string {
+	var out []string
+	for _, a := range s.Sorted() {
+		out = append(out, a.String())
+	}
+	return out
+}
+
+func TestMultiaddrSet(t *testing.T) {
+	a := StringCast("/ip4/1.2.3.4/tcp/1")
+	b := StringCast("/ip4/1.2.3.4/udp/1/quic-v1")
+	c := StringCast("/ip6/::1/tcp/1")
+	ws := StringCast("/ws")
+
+	var s MultiaddrSet
+	require.Zero(t, s.Len())
+	require.False(t, s.Has(a))
+	require.False(t, s.Remove(a))
+
+	s.Add(a, b, StringCast("/ip4/1.2.3.4/tcp/1"), ws)
+	require.Equal(t, 3, s.Len())
+	require.True(t, s.Has(a))
+	require.True(t, s.Has(ws))
+	require.False(t, s.Has(c))
+	require.False(t, s.Has(a[:1]), "prefixes are not members")
+	require.True(t, s.Remove(ws))
+	require.False(t, s.Has(ws))
+
+	other := NewMultiaddrSet(b, c)
+	require.Equal(t, []string{"/ip4/1.2.3.4/tcp/1", "/ip4/1.2.3.4/udp/1/quic-v1", "/ip6/::1/tcp/1"}, setStrings(s.Union(other)))
+	require.Equal(t, []string{"/ip4/1.2.3.4/udp/1/quic-v1"}, setStrings(s.Intersect(other)))
+	require.Equal(t, []string{"/ip4/1.2.3.4/tcp/1"}, setStrings(s.Difference(other)))
+	require.Equal(t, []string{"/ip6/::1/tcp/1"}, setStrings(other.Difference(&s)))
+
+	// Set operations don't modify their operands.
+	require.Equal(t, 2, s.Len())
+	require.Equal(t, 2, other.Len())
+
+	var nilSet *MultiaddrSet
+	require.False(t, nilSet.Has(a))
+	require.False(t, nilSet.Remove(a))
+	require.Zero(t, nilSet.Len())
+	require.Nil(t, nilSet.Sorted())
+	require.Equal(t, 2, nilSet.Union(other).Len())
+	require.Zero(t, nilSet.Intersect(other).Len())
+	require.Zero(t, other.Intersect(nilSet).Len())
+	require.Equal(t, 2, other.Difference(nilSet).Len())
+	require.Empty(t, slices.Collect(nilSet.All()))
+
+	require.ElementsMatch(t, s.Sorted(), slices.Collect(s.All()))
+
+	// Modifying an added multiaddr doesn't modify the set.
+	d := StringCast("/ip4/5.6.7.8/tcp/2")
+	s.Add(d)
+	d[0] = c[0]
+	require.True(t, s.Has(StringCast("/ip4/5.6.7.8/tcp/2")))
+	require.Contains(t, setStrings(&s), "/ip4/5.6.7.8/tcp/2")
+}
+
+func TestMultiaddrSetFilterAddrs(t *testing.T) {
+	addrs := []Multiaddr{
+		StringCast("/ip4/1.2.3.4/tcp/1"),
+		StringCast("/ip4/1.2.3.4/tcp/2"),
+		StringCast("/ip4/1.2.3.4/tcp/3"),
+	}
+	blocked := NewMultiaddrSet(addrs[1])
+	kept := FilterAddrs(addrs, func(m Multiaddr) bool { return !blocked.Has(m) })
+	require.Equal(t, []Multiaddr{addrs[0], addrs[2]}, kept)
+	require.Equal(t, []Multiaddr{addrs[1]}, FilterAddrs(addrs, blocked.Has))
+}
+
+func TestMultiaddrSetSortedMatchesUnique(t *testing.T) {
+	var addrs []Multiaddr
+	for i := 0; i < 100; i++ {
+		addrs = append(addrs, StringCast(fmt.Sprintf("/ip4/1.2.3.%d/tcp/%d", i%7, i%5)))
+	}
+	s := NewMultiaddrSet(addrs...)
+	require.Equal(t, Unique(slices.Clone(addrs)), s.Sorted())
+}
+
+func BenchmarkMultiaddrSetHas(b *testing.B) {
+	var addrs []Multiaddr
+	for i := 0; i < 1000; i++ {
+		addrs = append(addrs, StringCast(fmt.Sprintf("/ip4/1.2.%d.%d/tcp/%d", i/256, i%256, i)))
+	}
+	s := NewMultiaddrSet(addrs...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !s.Has(addrs[i%len(addrs)]) {
+			b.Fatal("missing")
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package multiaddr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoURLEquivalent is returned by ToURL for multiaddrs that can't be
+	// expressed as a URL.
+	ErrNoURLEquivalent = errors.New("multiaddr has no URL equivalent")
+	// ErrUnsupportedURL is returned by FromURL for URLs that can't be expressed
+	// as a multiaddr.
+	ErrUnsupportedURL = errors.New("URL has no multiaddr equivalent")
+)
+
+var defaultURLPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+}
+
+// ToURL converts a multiaddr to a URL. It understands multiaddrs of the form
+//
+//	<host>/tcp/<port>[/tls[/sni/<name>]]/{http,ws}[/http-path/<path>]
+//
+// where <host> is an /ip4, /ip6 (with an optional /ip6zone), /dns, /dns4 or
+// /dns6 component. The deprecated /https and /wss are treated as /tls/http
+// and /tls/ws. A bare <host>/tcp/<port> becomes a tcp://host:port URL. Default
+// ports are omitted.
+//
+// Multiaddrs that can't be represented, such as ones using UDP, /p2p or an SNI
+// that differs from the host, return an error wrapping ErrNoURLEquivalent.
+func ToURL(m Multiaddr) (*url.URL, error) {
+	m, err := NormalizeReplacements(m)
+	if err != nil {
+		return nil, err
+	}
+	orig := m
+	fail := func(format string, args ...any) (*url.URL, error) {
+		return nil, fmt.Errorf("%w: %s: %s", ErrNoURLEquivalent, orig, fmt.Sprintf(format, args...))
+	}
+	if len(m) == 0 {
+		return fail("empty multiaddr")
+	}
+
+	var host string
+	var hostIsDNS bool
+	zone := ""
+	if m[0].Code() == P_IP6ZONE {
+		zone = m[0].Value()
+		m = m[1:]
+		if len(m) == 0 || m[0].Code() != P_IP6 {
+			return fail("ip6zone must be followed by ip6")
+		}
+	}
+	switch m[0].Code() {
+	case P_IP4, P_IP6:
+		host = m[0].Value()
+		if zone != "" {
+			host += "%" + zone
+		}
+	case P_DNS, P_DNS4, P_DNS6:
+		host = m[0].Value()
+		hostIsDNS = true
+	default:
+		return fail("/%s can't be used as a URL host", m[0].Protocol().Name)
+	}
+	m = m[1:]
+
+	if len(m) == 0 || m[0].Code() != P_TCP {
+		return fail("expected /tcp after the host")
+	}
+	port := m[0].Value()
+	m = m[1:]
+
+	u := &url.URL{}
+	if len(m) == 0 {
+		u.Scheme = "tcp"
+		u.Host = net.JoinHostPort(host, port)
+		return u, nil
+	}
+
+	secure := false
+	if m[0].Code() == P_TLS {
+		secure = true
+		m = m[1:]
+		if len(m) > 0 && m[0].Code() == P_SNI {
+			sni := m[0].Value()
+			if !hostIsDNS || !strings.EqualFold(sni, host) {
+				return fail("sni %s differs from the host %s", sni, host)
+			}
+			m = m[1:]
+		}
+	}
+
+	if len(m) == 0 {
+		return fail("expected /http or /ws after /tls")
+	}
+	switch m[0].Code() {
+	case P_HTTP:
+		u.Scheme = "http"
+	case P_WS:
+		u.Scheme = "ws"
+	default:
+		return fail("/%s has no URL scheme", m[0].Protocol().Name)
+	}
+	if secure {
+		u.Scheme += "s"
+	}
+	m = m[1:]
+
+	if port == defaultURLPorts[u.Scheme] {
+		u.Host = host
+		if strings.Contains(host, ":") {
+			u.Host = "[" + host + "]"
+		}
+	} else {
+		u.Host = net.JoinHostPort(host, port)
+	}
+
+	if len(m) > 0 && m[0].Code() == P_HTTP_PATH {
+		path := string(m[0].RawValue())
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		u.Path = path
+		m = m[1:]
+	}
+	if len(m) > 0 {
+		return fail("unexpected /%s after the URL", m[0].Protocol().Name)
+	}
+	return u, nil
+}
+
+// FromURL converts a URL with an http, https, ws, wss or tcp scheme to a
+// multiaddr. It is the inverse of ToURL: https://example.com/api/v1 becomes
+// /dns/example.com/tcp/443/tls/http/http-path/api%2Fv1. Secure schemes use
+// /tls rather than the deprecated /https and /wss.
+//
+// URLs with user info, a query or a fragment, tcp URLs with a path, and paths
+// with an escaped slash (%2F), return an error wrapping ErrUnsupportedURL.
+func FromURL(u *url.URL) (Multiaddr, error) {
+	fail := func(format string, args ...any) (Multiaddr, error) {
+		return nil, fmt.Errorf("%w: %s: %s", ErrUnsupportedURL, u, fmt.Sprintf(format, args...))
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if _, ok := defaultURLPorts[scheme]; !ok && scheme != "tcp" {
+		return fail("unsupported scheme %q", u.Scheme)
+	}
+	if u.User != nil {
+		return fail("user info is not supported")
+	}
+	if u.RawQuery != "" || u.ForceQuery {
+		return fail("queries are not supported")
+	}
+	if u.Fragment != "" {
+		return fail("fragments are not supported")
+	}
+	if u.Opaque != "" {
+		return fail("opaque URLs are not supported")
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return fail("missing host")
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultURLPorts[scheme]
+		if port == "" {
+			return fail("missing port")
+		}
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fail("invalid port %q", port)
+	}
+
+	var out Multiaddr
+	appendComponent := func(protocol, value string) error {
+		c, err := NewComponent(protocol, value)
+		if err != nil {
+			return err
+		}
+		out = append(out, *c)
+		return nil
+	}
+
+	var err error
+	if addr, perr := netip.ParseAddr(host); perr == nil {
+		if addr.Zone() != "" {
+			err = appendComponent("ip6zone", addr.Zone())
+		}
+		if err == nil && addr.Is4() {
+			err = appendComponent("ip4", addr.String())
+		} else if err == nil {
+			err = appendComponent("ip6", addr.WithZone("").String())
+		}
+	} else {
+		err = appendComponent("dns", host)
+	}
+	if err == nil {
+		err = appendComponent("tcp", port)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrUnsupportedURL, u, err)
+	}
+
+	if scheme == "tcp" {
+		if u.Path != "" && u.Path != "/" {
+			return fail("tcp URLs can't have a path")
+		}
+		return out, nil
+	}
+
+	// http-path can't tell an escaped slash from a separator.
+	if strings.Contains(strings.ToUpper(u.EscapedPath()), "%2F") {
+		return fail("escaped slashes in the path are not supported")
+	}
+
+	if scheme == "https" || scheme == "wss" {
+		err = appendComponent("tls", "")
+	}
+	if err == nil && (scheme == "http" || scheme == "https") {
+		err = appendComponent("http", "")
+	} else if err == nil {
+		err = appendComponent("ws", "")
+	}
+	if path := strings.TrimPrefix(u.Path, "/"); err == nil && path != "" {
+		var c *Component
+		c, err = newComponentWithCode(P_HTTP_PATH, []byte(path))
+		if err == nil {
+			out = append(out, *c)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrUnsupportedURL, u, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,107 @@
+package multiaddr
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		addr string
+		url  string
+	}{
+		{"/dns/example.com/tcp/443/tls/http/http-path/api%2Fv1", "https://example.com/api/v1"},
+		{"/dns/example.com/tcp/80/http", "http://example.com"},
+		{"/dns/example.com/tcp/8080/http", "http://example.com:8080"},
+		{"/dns/example.com/tcp/443/tls/ws", "wss://example.com"},
+		{"/ip4/1.2.3.4/tcp/80/ws/http-path/socket", "ws://1.2.3.4/socket"},
+		{"/ip4/1.2.3.4/tcp/443/http", "http://1.2.3.4:443"},
+		{"/ip6/::1/tcp/8443/tls/http", "https://[::1]:8443"},
+		{"/ip6/::1/tcp/443/tls/http", "https://[::1]"},
+		{"/ip6zone/eth0/ip6/fe80::1/tcp/80/http", "http://[fe80::1%25eth0]"},
+		{"/ip4/1.2.3.4/tcp/4001", "tcp://1.2.3.4:4001"},
+		{"/ip6/::1/tcp/4001", "tcp://[::1]:4001"},
+	} {
+		t.Run(tc.addr, func(t *testing.T) {
+			u, err := ToURL(StringCast(tc.addr))
+			require.NoError(t, err)
+			require.Equal(t, tc.url, u.String())
+
+			parsed, err := url.Parse(tc.url)
+			require.NoError(t, err)
+			m, err := FromURL(parsed)
+			require.NoError(t, err)
+			require.Equal(t, tc.addr, m.String())
+		})
+	}
+}
+
+func TestToURL(t *testing.T) {
+	for _, tc := range []struct {
+		addr string
+		url  string
+	}{
+		{"/dns/example.com/tcp/443/https", "https://example.com"},
+		{"/dns4/example.com/tcp/443/wss", "wss://example.com"},
+		{"/dns6/example.com/tcp/443/tls/sni/EXAMPLE.com/http", "https://example.com"},
+		{"/dns/example.com/tcp/80/http/http-path/%2Fa%2Fb", "http://example.com/a/b"},
+	} {
+		u, err := ToURL(StringCast(tc.addr))
+		require.NoError(t, err, tc.addr)
+		require.Equal(t, tc.url, u.String(), tc.addr)
+	}
+
+	for _, addr := range []string{
+		"/ip4/1.2.3.4/udp/443/quic-v1",
+		"/dnsaddr/example.com",
+		"/ip4/1.2.3.4/tcp/443/tls/sni/example.com/http",
+		"/dns/example.com/tcp/443/tls/sni/example.org/http",
+		"/ip4/1.2.3.4/tcp/443/tls",
+		"/ip4/1.2.3.4/tcp/443/tls/noise",
+		"/ip4/1.2.3.4/tcp/80/http/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC",
+		"/ip6zone/eth0/tcp/80",
+	} {
+		_, err := ToURL(StringCast(addr))
+		require.ErrorIs(t, err, ErrNoURLEquivalent, addr)
+	}
+	_, err := ToURL(nil)
+	require.ErrorIs(t, err, ErrNoURLEquivalent)
+}
+
+func TestFromURL(t *testing.T) {
+	for _, tc := range []struct {
+		url  string
+		addr string
+	}{
+		{"HTTPS://example.com:443/", "/dns/example.com/tcp/443/tls/http"},
+		{"tcp://example.com:1234/", "/dns/example.com/tcp/1234"},
+		{"https://example.com/a/b/c", "/dns/example.com/tcp/443/tls/http/http-path/a%2Fb%2Fc"},
+		{"wss://example.com/a%20b", "/dns/example.com/tcp/443/tls/ws/http-path/a+b"},
+	} {
+		u, err := url.Parse(tc.url)
+		require.NoError(t, err)
+		m, err := FromURL(u)
+		require.NoError(t, err, tc.url)
+		require.Equal(t, tc.addr, m.String(), tc.url)
+	}
+
+	for _, s := range []string{
+		"ftp://example.com",
+		"tcp://example.com",
+		"tcp://example.com:1234/path",
+		"https://user@example.com",
+		"https://example.com/?q=1",
+		"https://example.com/#frag",
+		"https://example.com:99999",
+		"https://example.com/a%2Fb/c",
+		"https://example.com/a%2fb",
+		"mailto:someone@example.com",
+	} {
+		u, err := url.Parse(s)
+		require.NoError(t, err)
+		_, err = FromURL(u)
+		require.ErrorIs(t, err, ErrUnsupportedURL, s)
+	}
+}
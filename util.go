@@ -127,3 +127,21 @@ func (m Multiaddr) Match(p ...meg.Pattern) (bool, error) {
 	matcher := meg.PatternToMatcher(p...)
 	return meg.Match(matcher, m)
 }
+
+// MatchCaptures matches m against a compiled matcher, and returns the named
+// captures. The captured values are *Component pointing into m. See
+// meg.MatchCaptures.
+func (m Multiaddr) MatchCaptures(matcher meg.Matcher) (meg.Captures, bool, error) {
+	return meg.MatchCaptures(matcher, m)
+}
+
+// ExplainMatch matches m against matcher, and returns nil if it matches.
+// Otherwise it returns a *MatchError saying where and why matching failed,
+// such as "expected tcp or udp after ip4, got sctp".
+func (m Multiaddr) ExplainMatch(matcher meg.Matcher) error {
+	d := meg.Explain(matcher, m)
+	if d.Matched {
+		return nil
+	}
+	return &MatchError{Addr: m, Diagnosis: d}
+}
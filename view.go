@@ -0,0 +1,237 @@
+package multiaddr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// MultiaddrView is a read-only view over the binary representation of a
+// multiaddr.
+//
+// Unlike NewMultiaddrBytes, creating a view neither copies nor validates the
+// input. Components are decoded lazily as they are visited, and their values
+// are only checked when Validate is called. Use ToMultiaddr to convert the view
+// into a Multiaddr once the full representation is needed.
+//
+// A MultiaddrView does not own its bytes. The caller must not modify the
+// underlying slice while the view, or any ComponentView obtained from it, is in
+// use.
+type MultiaddrView struct {
+	b        []byte
+	registry *Registry
+}
+
+// NewMultiaddrView returns a view over b. It does not copy or validate b.
+func NewMultiaddrView(b []byte) MultiaddrView {
+	return defaultRegistry.NewMultiaddrView(b)
+}
+
+// Bytes returns the bytes the view was created with. The returned slice is not
+// a copy.
+func (v MultiaddrView) Bytes() []byte {
+	return v.b
+}
+
+// Empty reports whether the view has no bytes.
+func (v MultiaddrView) Empty() bool {
+	return len(v.b) == 0
+}
+
+// ForEach decodes the components of the view in order and calls cb for each of
+// them, stopping early if cb returns false. It does not allocate.
+//
+// Only the framing of the components is checked, their values are not
+// validated. The returned error reports malformed framing, including any found
+// before cb asked to stop.
+func (v MultiaddrView) ForEach(cb func(c ComponentView) bool) error {
+	r := v.registry
+	if r == nil {
+		r = defaultRegistry
+	}
+	b := v.b
+	sawPathComponent := false
+	for i := 0; len(b) > 0; i++ {
+		offset := len(v.b) - len(b)
+		c, err := readComponentView(r, b)
+		if err != nil {
+			return withPosition(err, offset, i)
+		}
+		if sawPathComponent {
+			// It is an error to have another component after a path component.
+			return &ParseError{
+				Offset:    offset,
+				Component: i,
+				Protocol:  c.protocol.Name,
+				Err:       fmt.Errorf("%w: unexpected component after path component", ErrTrailingData),
+			}
+		}
+		sawPathComponent = c.protocol.Path
+		b = b[len(c.b):]
+		if !cb(c) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Validate checks the view with the same rules as NewMultiaddrBytes. A view
+// that passes validation can be converted with ToMultiaddr without error.
+func (v MultiaddrView) Validate() error {
+	if len(v.b) == 0 {
+		return &ParseError{Component: -1, Err: ErrEmptyMultiaddr}
+	}
+	var valueErr error
+	var i, offset int
+	err := v.ForEach(func(c ComponentView) bool {
+		if err := c.Validate(); err != nil {
+			valueErr = &ParseError{
+				Offset:    offset + c.valueStartIdx,
+				Component: i,
+				Protocol:  c.protocol.Name,
+				Err:       invalidValueError("", err),
+			}
+			return false
+		}
+		i++
+		offset += len(c.b)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return valueErr
+}
+
+// ToMultiaddr validates the view and copies it into a new Multiaddr.
+func (v MultiaddrView) ToMultiaddr() (Multiaddr, error) {
+	r := v.registry
+	if r == nil {
+		r = defaultRegistry
+	}
+	return r.parser.NewMultiaddrBytes(v.b)
+}
+
+// Equal reports whether both views hold the same bytes.
+func (v MultiaddrView) Equal(o MultiaddrView) bool {
+	return bytes.Equal(v.b, o.b)
+}
+
+// EqualMultiaddr reports whether the view holds the binary representation of m.
+// It does not allocate.
+func (v MultiaddrView) EqualMultiaddr(m Multiaddr) bool {
+	b := v.b
+	for _, c := range m {
+		if len(b) < len(c.bytes) || string(b[:len(c.bytes)]) != c.bytes {
+			return false
+		}
+		b = b[len(c.bytes):]
+	}
+	return len(b) == 0
+}
+
+// String returns the string representation of the view. Formatting stops at
+// the first malformed component.
+func (v MultiaddrView) String() string {
+	var b strings.Builder
+	_ = v.ForEach(func(c ComponentView) bool {
+		c.writeTo(&b)
+		return true
+	})
+	return b.String()
+}
+
+// ComponentView is a single component of a MultiaddrView. It references the
+// bytes of the view it was decoded from.
+type ComponentView struct {
+	// b holds the whole component: the protocol code as varint, possibly the
+	// size of the value, and the value.
+	b             []byte
+	protocol      *Protocol
+	valueStartIdx int
+}
+
+func readComponentView(r *Registry, b []byte) (ComponentView, error) {
+	p, valueStartIdx, end, err := readComponentHeader(r, b)
+	if err != nil {
+		return ComponentView{}, err
+	}
+	return ComponentView{
+		b:             b[:end],
+		protocol:      p,
+		valueStartIdx: valueStartIdx,
+	}, nil
+}
+
+func (c ComponentView) Protocol() Protocol {
+	if c.protocol == nil {
+		return Protocol{}
+	}
+	return *c.protocol
+}
+
+func (c ComponentView) Code() int {
+	if c.protocol == nil {
+		return 0
+	}
+	return c.protocol.Code
+}
+
+// Bytes returns the binary representation of the component. The returned slice
+// is not a copy.
+func (c ComponentView) Bytes() []byte {
+	return c.b
+}
+
+// RawValue returns the bytes of the component's value. The returned slice is
+// not a copy.
+func (c ComponentView) RawValue() []byte {
+	return c.b[c.valueStartIdx:]
+}
+
+// Value returns the string representation of the component's value, or an
+// empty string if the value can't be decoded.
+func (c ComponentView) Value() string {
+	if c.protocol == nil || c.protocol.Transcoder == nil {
+		return ""
+	}
+	value, err := c.protocol.Transcoder.BytesToString(c.RawValue())
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// Validate checks the component's value against its protocol.
+func (c ComponentView) Validate() error {
+	if c.protocol == nil {
+		return fmt.Errorf("component is missing its protocol")
+	}
+	return validateComponentValue(c.protocol, c.RawValue())
+}
+
+// Component validates the component and copies it into a new Component.
+func (c ComponentView) Component() (*Component, error) {
+	comp := &Component{
+		bytes:         string(c.b),
+		protocol:      c.protocol,
+		valueStartIdx: c.valueStartIdx,
+	}
+	if err := validateComponent(comp); err != nil {
+		return nil, err
+	}
+	return comp, nil
+}
+
+func (c ComponentView) String() string {
+	var b strings.Builder
+	c.writeTo(&b)
+	return b.String()
+}
+
+func (c ComponentView) writeTo(b *strings.Builder) {
+	if c.protocol == nil {
+		return
+	}
+	writeComponentTo(b, c.protocol, c.Value())
+}
@@ -0,0 +1,85 @@
+package multiaddr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiaddrView(t *testing.T) {
+	for _, s := range good {
+		m := StringCast(s)
+		v := NewMultiaddrView(m.Bytes())
+		require.NoError(t, v.Validate(), s)
+		require.True(t, v.EqualMultiaddr(m), s)
+		require.Equal(t, m.String(), v.String())
+
+		var i int
+		err := v.ForEach(func(c ComponentView) bool {
+			require.Equal(t, m[i].Code(), c.Code())
+			require.Equal(t, m[i].Bytes(), c.Bytes())
+			require.Equal(t, m[i].RawValue(), c.RawValue())
+			require.Equal(t, m[i].Value(), c.Value())
+
+			comp, err := c.Component()
+			require.NoError(t, err)
+			require.True(t, comp.Equal(&m[i]))
+			i++
+			return true
+		})
+		require.NoError(t, err)
+		require.Equal(t, len(m), i)
+
+		m2, err := v.ToMultiaddr()
+		require.NoError(t, err)
+		require.True(t, m.Equal(m2))
+	}
+}
+
+func TestMultiaddrViewStopsEarly(t *testing.T) {
+	v := NewMultiaddrView(StringCast("/ip4/1.2.3.4/tcp/1234/ws").Bytes())
+	var codes []int
+	err := v.ForEach(func(c ComponentView) bool {
+		codes = append(codes, c.Code())
+		return c.Code() != P_TCP
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{P_IP4, P_TCP}, codes)
+}
+
+func TestMultiaddrViewInvalid(t *testing.T) {
+	require.Error(t, NewMultiaddrView(nil).Validate())
+
+	// Truncated ip4 value.
+	b := StringCast("/ip4/1.2.3.4").Bytes()
+	require.Error(t, NewMultiaddrView(b[:3]).Validate())
+	require.Error(t, NewMultiaddrView(b[:3]).ForEach(func(ComponentView) bool { return true }))
+
+	// The framing of an invalid peer ID is fine, only its value is not.
+	p2p := []byte{0xa5, 0x03, 0x03, 0x01, 0x02, 0x03}
+	v := NewMultiaddrView(p2p)
+	require.NoError(t, v.ForEach(func(ComponentView) bool { return true }))
+	require.Error(t, v.Validate())
+	_, err := v.ToMultiaddr()
+	require.Error(t, err)
+
+	// Nothing may follow a path component.
+	unix := append(StringCast("/unix/a/b").Bytes(), StringCast("/tcp/1").Bytes()...)
+	require.Error(t, NewMultiaddrView(unix).Validate())
+}
+
+func TestMultiaddrViewDoesNotAllocate(t *testing.T) {
+	m := StringCast("/ip4/1.2.3.4/udp/1234/quic-v1/webtransport/certhash/uEiDDq4_xNyDorZBH3TlGazyJdOWSwvo4PUo5YHFMrvDE8g")
+	v := NewMultiaddrView(m.Bytes())
+	allocs := testing.AllocsPerRun(100, func() {
+		var n int
+		_ = v.ForEach(func(c ComponentView) bool {
+			n += len(c.RawValue())
+			return true
+		})
+		if !v.EqualMultiaddr(m) {
+			t.Fatal("expected view to equal multiaddr")
+		}
+	})
+	require.Zero(t, allocs)
+}
@@ -343,10 +343,8 @@ func BenchmarkIsWebTransportMultiaddrPrealloc(b *testing.B) {
 	}
 }
 
-func BenchmarkIsWebTransportMultiaddrNoCapturePrealloc(b *testing.B) {
-	addr := multiaddr.StringCast("/ip4/1.2.3.4/udp/1234/quic-v1/sni/example.com/webtransport")
-
-	wtPreallocNoCapture := meg.PatternToMatcher(
+func wtNoCaptureMatcher() meg.Matcher {
+	return meg.PatternToMatcher(
 		meg.Or(
 			meg.Val(multiaddr.P_IP4),
 			meg.Val(multiaddr.P_IP6),
@@ -362,6 +360,29 @@ func BenchmarkIsWebTransportMultiaddrNoCapturePrealloc(b *testing.B) {
 		meg.Val(multiaddr.P_WEBTRANSPORT),
 		meg.ZeroOrMore(multiaddr.P_CERTHASH),
 	)
+}
+
+func BenchmarkIsWebTransportMultiaddrNoCapturePrealloc(b *testing.B) {
+	addr := multiaddr.StringCast("/ip4/1.2.3.4/udp/1234/quic-v1/sni/example.com/webtransport")
+
+	wtPreallocNoCapture := wtNoCaptureMatcher()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		isWT, _ := meg.Match(wtPreallocNoCapture, addr)
+		if !isWT {
+			b.Fatal("unexpected result")
+		}
+	}
+}
+
+func BenchmarkIsWebTransportMultiaddrNoCapturePreallocDFA(b *testing.B) {
+	addr := multiaddr.StringCast("/ip4/1.2.3.4/udp/1234/quic-v1/sni/example.com/webtransport")
+
+	wtPreallocNoCapture, err := wtNoCaptureMatcher().WithDFA()
+	if err != nil {
+		b.Fatal(err)
+	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -444,6 +465,31 @@ func BenchmarkIsWebRTCDirectMultiaddr(b *testing.B) {
 	}
 }
 
+func BenchmarkIsWebRTCDirectMultiaddrNoCaptureDFA(b *testing.B) {
+	addr := multiaddr.StringCast("/ip4/1.2.3.4/udp/1234/webrtc-direct/")
+	m, err := meg.PatternToMatcher(
+		meg.Or(
+			meg.Val(multiaddr.P_IP4),
+			meg.Val(multiaddr.P_IP6),
+			meg.Val(multiaddr.P_DNS),
+		),
+		meg.Val(multiaddr.P_UDP),
+		meg.Val(multiaddr.P_WEBRTC_DIRECT),
+		meg.ZeroOrMore(multiaddr.P_CERTHASH),
+	).WithDFA()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		isWebRTC, _ := meg.Match(m, addr)
+		if !isWebRTC {
+			b.Fatal("unexpected result")
+		}
+	}
+}
+
 func BenchmarkIsWebRTCDirectMultiaddrLoop(b *testing.B) {
 	addr := multiaddr.StringCast("/ip4/1.2.3.4/udp/1234/webrtc-direct/")
 
@@ -455,3 +501,101 @@ func BenchmarkIsWebRTCDirectMultiaddrLoop(b *testing.B) {
 		}
 	}
 }
+
+var wtNamedMatcher = meg.PatternToMatcher(
+	meg.CaptureNamed("host", meg.Or(
+		meg.Val(multiaddr.P_IP4),
+		meg.Val(multiaddr.P_IP6),
+		meg.Val(multiaddr.P_DNS4),
+		meg.Val(multiaddr.P_DNS6),
+		meg.Val(multiaddr.P_DNS),
+	)),
+	meg.CaptureNamed("port", meg.Val(multiaddr.P_UDP)),
+	meg.Val(multiaddr.P_QUIC_V1),
+	meg.Optional(
+		meg.CaptureNamed("sni", meg.Val(multiaddr.P_SNI)),
+	),
+	meg.Val(multiaddr.P_WEBTRANSPORT),
+	meg.CaptureNamed("certhash", meg.ZeroOrMore(multiaddr.P_CERTHASH)),
+)
+
+func BenchmarkIsWebTransportMultiaddrNamedCaptures(b *testing.B) {
+	addr := multiaddr.StringCast("/ip4/1.2.3.4/udp/1234/quic-v1/sni/example.com/webtransport")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		caps, isWT, _ := meg.MatchCaptures(wtNamedMatcher, addr)
+		if !isWT || len(caps["certhash"]) != 0 {
+			b.Fatal("unexpected result")
+		}
+	}
+}
+
+var ipOrDNS = meg.Or(
+	meg.Val(multiaddr.P_IP4),
+	meg.Val(multiaddr.P_IP6),
+	meg.Val(multiaddr.P_DNS),
+	meg.Val(multiaddr.P_DNS4),
+	meg.Val(multiaddr.P_DNS6),
+)
+
+var addrShapes = []meg.Pattern{
+	meg.Cat(ipOrDNS, meg.Val(multiaddr.P_TCP), meg.Optional(meg.Val(multiaddr.P_P2P))),
+	meg.Cat(ipOrDNS, meg.Val(multiaddr.P_TCP), meg.Val(multiaddr.P_TLS), meg.Optional(meg.Val(multiaddr.P_SNI)), meg.Val(multiaddr.P_WS), meg.Optional(meg.Val(multiaddr.P_P2P))),
+	meg.Cat(ipOrDNS, meg.Val(multiaddr.P_TCP), meg.Val(multiaddr.P_WS), meg.Optional(meg.Val(multiaddr.P_P2P))),
+	meg.Cat(ipOrDNS, meg.Val(multiaddr.P_UDP), meg.Val(multiaddr.P_QUIC_V1), meg.Optional(meg.Val(multiaddr.P_P2P))),
+	meg.Cat(ipOrDNS, meg.Val(multiaddr.P_UDP), meg.Val(multiaddr.P_QUIC_V1), meg.Optional(meg.Val(multiaddr.P_SNI)), meg.Val(multiaddr.P_WEBTRANSPORT), meg.ZeroOrMore(multiaddr.P_CERTHASH), meg.Optional(meg.Val(multiaddr.P_P2P))),
+	meg.Cat(ipOrDNS, meg.Val(multiaddr.P_UDP), meg.Val(multiaddr.P_WEBRTC_DIRECT), meg.ZeroOrMore(multiaddr.P_CERTHASH), meg.Optional(meg.Val(multiaddr.P_P2P))),
+	meg.Cat(meg.OneOrMore(meg.Any), meg.Val(multiaddr.P_CIRCUIT), meg.Optional(meg.Val(multiaddr.P_P2P))),
+	meg.Cat(meg.Val(multiaddr.P_DNSADDR), meg.Optional(meg.Val(multiaddr.P_P2P))),
+}
+
+func BenchmarkClassifier(b *testing.B) {
+	addr := multiaddr.StringCast("/ip4/1.2.3.4/udp/1234/quic-v1/sni/example.com/webtransport")
+	c := meg.NewClassifier(addrShapes...)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ms, _ := meg.Classify(c, addr)
+		if len(ms) != 1 || ms[0].Index != 4 {
+			b.Fatal("unexpected result")
+		}
+	}
+}
+
+func BenchmarkClassifierDFA(b *testing.B) {
+	addr := multiaddr.StringCast("/ip4/1.2.3.4/udp/1234/quic-v1/sni/example.com/webtransport")
+	c, err := meg.NewClassifier(addrShapes...).WithDFA()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ms, _ := meg.Classify(c, addr)
+		if len(ms) != 1 || ms[0].Index != 4 {
+			b.Fatal("unexpected result")
+		}
+	}
+}
+
+func BenchmarkClassifierSeparateMatchers(b *testing.B) {
+	addr := multiaddr.StringCast("/ip4/1.2.3.4/udp/1234/quic-v1/sni/example.com/webtransport")
+	matchers := make([]meg.Matcher, len(addrShapes))
+	for i, p := range addrShapes {
+		matchers[i] = meg.PatternToMatcher(p)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var found []int
+		for j, m := range matchers {
+			if ok, _ := meg.Match(m, addr); ok {
+				found = append(found, j)
+			}
+		}
+		if len(found) != 1 || found[0] != 4 {
+			b.Fatal("unexpected result")
+		}
+	}
+}
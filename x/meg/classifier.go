@@ -0,0 +1,173 @@
+package meg
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// Classifier matches components against many patterns in a single pass over
+// the components. The patterns are compiled into one automaton with a done
+// state per pattern, which holds the pattern's index in its next field.
+//
+// A Classifier is immutable and safe for concurrent use, as long as its
+// patterns only use named captures.
+type Classifier struct {
+	matcher Matcher
+	n       int
+	// scratch holds *classifierScratch values, to avoid allocating the
+	// thread lists, which are larger than Match's for many patterns.
+	scratch sync.Pool
+}
+
+type classifierScratch struct {
+	visited       []uint64
+	current, next statesAndCaptures
+}
+
+// ClassifierMatch is a pattern matched by a Classifier.
+type ClassifierMatch struct {
+	// Index is the index of the pattern in the NewClassifier arguments.
+	Index int
+	// Captures are the named captures of the pattern.
+	Captures Captures
+}
+
+// NewClassifier returns a Classifier for patterns. Use Cat to pass a sequence
+// of patterns as one.
+func NewClassifier(patterns ...Pattern) *Classifier {
+	states := make([]MatchState, 0, len(patterns)*4)
+	starts := make([]int, len(patterns))
+	for i, p := range patterns {
+		states = append(states, MatchState{codeOrKind: done, next: i})
+		states, starts[i] = p(states, len(states)-1)
+	}
+	if len(patterns) == 0 {
+		return &Classifier{matcher: Matcher{states: states}}
+	}
+	// Chain the start states with splits.
+	startIdx := starts[len(starts)-1]
+	for i := len(starts) - 2; i >= 0; i-- {
+		states = append(states, MatchState{next: starts[i], codeOrKind: encodeSplitIdx(startIdx)})
+		startIdx = len(states) - 1
+	}
+	return &Classifier{
+		matcher: Matcher{states: states, startIdx: startIdx},
+		n:       len(patterns),
+	}
+}
+
+// WithDFA returns a copy of the classifier that uses a DFA, like
+// Matcher.WithDFA. Classify then only allocates its result, but doesn't
+// return named captures.
+func (c *Classifier) WithDFA() (*Classifier, error) {
+	m, err := c.matcher.WithDFA()
+	if err != nil {
+		return nil, err
+	}
+	return &Classifier{matcher: m, n: c.n}, nil
+}
+
+// Len returns the number of patterns of the classifier.
+func (c *Classifier) Len() int {
+	return c.n
+}
+
+// Classify returns the patterns of c that match components, ordered by index.
+// Capture funcs are called for every matching pattern, in that order.
+func Classify[T any, PT interface {
+	*T
+	Matchable
+}](c *Classifier, components []T) ([]ClassifierMatch, error) {
+	if d := c.matcher.dfa; d != nil {
+		state := dfaRun[T, PT](d, components)
+		if state < 0 || len(d.done[state]) == 0 {
+			return nil, nil
+		}
+		matches := make([]ClassifierMatch, len(d.done[state]))
+		for i, idx := range d.done[state] {
+			matches[i].Index = idx
+		}
+		return matches, nil
+	}
+
+	states := c.matcher.states
+	preferExact := c.matcher.anyPriority == PreferExact
+
+	scratch, _ := c.scratch.Get().(*classifierScratch)
+	if scratch == nil {
+		// A thread list never holds more than one thread per state.
+		scratch = &classifierScratch{
+			visited: make([]uint64, (len(states)+63)/64),
+			current: statesAndCaptures{
+				states:   make([]int, 0, len(states)),
+				captures: make([]*capture, 0, len(states)),
+			},
+			next: statesAndCaptures{
+				states:   make([]int, 0, len(states)),
+				captures: make([]*capture, 0, len(states)),
+			},
+		}
+	}
+	defer func() {
+		// Don't keep the captured components alive.
+		clear(scratch.current.captures[:cap(scratch.current.captures)])
+		clear(scratch.next.captures[:cap(scratch.next.captures)])
+		scratch.current.states = scratch.current.states[:0]
+		scratch.current.captures = scratch.current.captures[:0]
+		scratch.next.states = scratch.next.states[:0]
+		scratch.next.captures = scratch.next.captures[:0]
+		clear(scratch.visited)
+		c.scratch.Put(scratch)
+	}()
+	visitedBitSet := scratch.visited
+	currentStates, nextStates := scratch.current, scratch.next
+
+	currentStates = appendState(currentStates, states, c.matcher.startIdx, nil, 0, 0, visitedBitSet, preferExact)
+
+	for ic := range len(components) {
+		if len(currentStates.states) == 0 {
+			return nil, nil
+		}
+		clear(visitedBitSet)
+		for i, stateIndex := range currentStates.states {
+			s := &states[stateIndex]
+			cPtr := PT(&components[ic])
+			if (s.codeOrKind == matchAny || (s.codeOrKind >= 0 && s.codeOrKind == cPtr.Code())) &&
+				(s.pred == nil || s.pred(cPtr)) {
+				cm := s.pushCapture(currentStates.captures[i], cPtr, true)
+				nextStates = appendState(nextStates, states, s.next, cm, 0, ic+1, visitedBitSet, preferExact)
+			}
+		}
+		currentStates, nextStates = nextStates, currentStates
+		nextStates.states = nextStates.states[:0]
+		nextStates.captures = nextStates.captures[:0]
+	}
+
+	// Each pattern has its own done state, so it's reached at most once, by
+	// the preferred path of the pattern.
+	type doneState struct {
+		index int
+		cap   *capture
+	}
+	var found []doneState
+	for i, stateIndex := range currentStates.states {
+		if s := &states[stateIndex]; s.codeOrKind == done {
+			found = append(found, doneState{s.next, currentStates.captures[i]})
+		}
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	slices.SortFunc(found, func(a, b doneState) int { return cmp.Compare(a.index, b.index) })
+
+	matches := make([]ClassifierMatch, len(found))
+	for i, d := range found {
+		named, err := runCaptures(d.cap, true, components)
+		if err != nil {
+			return nil, err
+		}
+		matches[i] = ClassifierMatch{Index: d.index, Captures: named}
+	}
+	return matches, nil
+}
@@ -0,0 +1,101 @@
+package meg
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func matchIndexes(ms []ClassifierMatch) []int {
+	var out []int
+	for _, m := range ms {
+		out = append(out, m.Index)
+	}
+	return out
+}
+
+func TestClassifier(t *testing.T) {
+	patterns := []Pattern{
+		Cat(Val(1), Val(2)),
+		Cat(Val(1), ZeroOrMore(Any)),
+		Cat(CaptureNamed("x", Val(1)), CaptureNamed("rest", OneOrMore(3))),
+		Val(4),
+		Cat(ZeroOrMore(Any), Val(3)),
+	}
+	c := NewClassifier(patterns...)
+	if c.Len() != len(patterns) {
+		t.Fatalf("unexpected length %d", c.Len())
+	}
+
+	for _, tc := range []struct {
+		codes   []int
+		matches []int
+	}{
+		{[]int{1, 2}, []int{0, 1}},
+		{[]int{1}, []int{1}},
+		{[]int{1, 3, 3}, []int{1, 2, 4}},
+		{[]int{4}, []int{3}},
+		{[]int{5}, nil},
+		{nil, nil},
+	} {
+		ms, err := Classify(c, codesToCodeAndValue(tc.codes))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := matchIndexes(ms); !slices.Equal(got, tc.matches) {
+			t.Errorf("%v: expected %v, got %v", tc.codes, tc.matches, got)
+		}
+
+		// A classifier gives the same results as separate matchers.
+		var want []int
+		for i, p := range patterns {
+			if found, _ := Match(PatternToMatcher(p), codesToCodeAndValue(tc.codes)); found {
+				want = append(want, i)
+			}
+		}
+		if !slices.Equal(want, tc.matches) {
+			t.Errorf("%v: separate matchers matched %v", tc.codes, want)
+		}
+	}
+
+	ms, err := Classify(c, []codeAndValue{{1, "a"}, {3, "b"}, {3, "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms[0].Captures != nil {
+		t.Fatalf("unexpected captures %v", ms[0].Captures)
+	}
+	if ms[1].Captures.Get("x").Value() != "a" || !slices.Equal(ms[1].Captures.Values("rest"), []string{"b", "c"}) {
+		t.Fatalf("unexpected captures %v", ms[1].Captures)
+	}
+
+	if ms, _ := Classify(NewClassifier(), codesToCodeAndValue([]int{1})); ms != nil {
+		t.Fatal("an empty classifier shouldn't match")
+	}
+}
+
+func TestClassifierCaptureFuncs(t *testing.T) {
+	errBoom := errors.New("boom")
+	var calls []int
+	c := NewClassifier(
+		CaptureWithF(1, func(Matchable) error {
+			calls = append(calls, 0)
+			return nil
+		}),
+		CaptureWithF(1, func(Matchable) error {
+			calls = append(calls, 1)
+			return errBoom
+		}),
+		CaptureWithF(1, func(Matchable) error {
+			calls = append(calls, 2)
+			return nil
+		}),
+	)
+	_, err := Classify(c, codesToCodeAndValue([]int{1}))
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected the capture error, got %v", err)
+	}
+	if !slices.Equal(calls, []int{0, 1}) {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+}
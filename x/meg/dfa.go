@@ -0,0 +1,173 @@
+package meg
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxDFAStates is the maximum number of states of a DFA built by WithDFA.
+const MaxDFAStates = 4096
+
+var (
+	// ErrDFAUnsupported is returned by WithDFA for matchers with capture funcs
+	// or predicates, as the DFA doesn't track paths or component values.
+	ErrDFAUnsupported = errors.New("DFA not supported for matchers with capture funcs or predicates")
+	// ErrDFATooLarge is returned by WithDFA when the DFA would have more than
+	// MaxDFAStates states.
+	ErrDFATooLarge = errors.New("DFA exceeds the maximum number of states")
+)
+
+// dfa is a deterministic automaton equivalent to a Matcher's NFA, built with
+// the subset construction. Each DFA state is the set of NFA states a
+// simulation would hold after reading the same components.
+type dfa struct {
+	// codes is the sorted set of codes that appear in the NFA.
+	codes []int
+	// trans holds one row of len(codes)+1 transitions per state. The last
+	// column is for codes that aren't in codes, which only Any matches. A
+	// transition to -1 is a dead end.
+	trans []int32
+	// done holds the sorted indexes of the done states reached by each
+	// state. For a Matcher the only index is 0; a Classifier has one per
+	// pattern.
+	done  [][]int
+	start int32
+}
+
+// WithDFA returns a copy of the matcher that uses a DFA for Match. Match then
+// takes time linear in the number of components and doesn't allocate. The
+// DFA is built ahead of time, and takes more memory than the matcher.
+//
+// Matchers with capture funcs or predicates can't use a DFA. Named captures
+// are allowed: Match ignores them, and MatchCaptures and the search functions
+// keep using the NFA.
+func (s Matcher) WithDFA() (Matcher, error) {
+	d, err := buildDFA(s.states, s.startIdx)
+	if err != nil {
+		return Matcher{}, err
+	}
+	s.dfa = d
+	return s, nil
+}
+
+// HasDFA reports whether the matcher uses a DFA for Match.
+func (s Matcher) HasDFA() bool {
+	return s.dfa != nil
+}
+
+func buildDFA(states []MatchState, startIdx int) (*dfa, error) {
+	d := &dfa{}
+	for _, s := range states {
+		if s.capture != nil || s.pred != nil || s.group != nil {
+			return nil, ErrDFAUnsupported
+		}
+		if s.codeOrKind >= 0 && !slices.Contains(d.codes, s.codeOrKind) {
+			d.codes = append(d.codes, s.codeOrKind)
+		}
+	}
+	slices.Sort(d.codes)
+	cols := len(d.codes) + 1
+
+	visited := make([]uint64, (len(states)+63)/64)
+	// closure returns the sorted set of match and done states reachable from
+	// the given states.
+	closure := func(from []int) []int {
+		clear(visited)
+		var arr statesAndCaptures
+		for _, idx := range from {
+			arr = appendState(arr, states, idx, nil, 0, 0, visited, false)
+		}
+		slices.Sort(arr.states)
+		return arr.states
+	}
+	key := func(set []int) string {
+		var b strings.Builder
+		for _, idx := range set {
+			b.WriteString(strconv.Itoa(idx))
+			b.WriteByte(',')
+		}
+		return b.String()
+	}
+
+	var sets [][]int
+	ids := map[string]int32{}
+	add := func(set []int) (int32, error) {
+		if len(set) == 0 {
+			return -1, nil
+		}
+		k := key(set)
+		if id, ok := ids[k]; ok {
+			return id, nil
+		}
+		if len(sets) == MaxDFAStates {
+			return 0, ErrDFATooLarge
+		}
+		id := int32(len(sets))
+		ids[k] = id
+		sets = append(sets, set)
+		var reached []int
+		for _, idx := range set {
+			if states[idx].codeOrKind == done {
+				reached = append(reached, states[idx].next)
+			}
+		}
+		slices.Sort(reached)
+		d.done = append(d.done, reached)
+		d.trans = append(d.trans, make([]int32, cols)...)
+		return id, nil
+	}
+
+	var err error
+	// The start state is -1 if the NFA can't match anything.
+	if d.start, err = add(closure([]int{startIdx})); err != nil {
+		return nil, err
+	}
+	var next []int
+	// sets grows as new states are discovered.
+	for id := 0; id < len(sets); id++ {
+		for col := range cols {
+			next = next[:0]
+			for _, idx := range sets[id] {
+				s := &states[idx]
+				if s.codeOrKind == matchAny || (col < len(d.codes) && s.codeOrKind == d.codes[col]) {
+					next = append(next, s.next)
+				}
+			}
+			to, err := add(closure(next))
+			if err != nil {
+				return nil, err
+			}
+			d.trans[id*cols+col] = to
+		}
+	}
+	return d, nil
+}
+
+// column returns the transition column of code.
+func (d *dfa) column(code int) int {
+	if i, ok := slices.BinarySearch(d.codes, code); ok {
+		return i
+	}
+	return len(d.codes)
+}
+
+// dfaRun returns the final state after reading components, or -1.
+func dfaRun[T any, PT interface {
+	*T
+	Matchable
+}](d *dfa, components []T) int32 {
+	cols := len(d.codes) + 1
+	state := d.start
+	if state < 0 {
+		return -1
+	}
+	for i := range components {
+		state = d.trans[int(state)*cols+d.column(PT(&components[i]).Code())]
+		if state < 0 {
+			return -1
+		}
+	}
+	return state
+}
@@ -0,0 +1,148 @@
+package meg
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// randomPattern returns a random pattern over the codes 0 to 3, without
+// captures or predicates.
+func randomPattern(rng *rand.Rand, depth int) Pattern {
+	code := rng.Intn(4)
+	if rng.Intn(8) == 0 {
+		code = Any
+	}
+	if depth == 0 {
+		return Val(code)
+	}
+	switch rng.Intn(8) {
+	case 0:
+		return Cat(randomPattern(rng, depth-1), randomPattern(rng, depth-1))
+	case 1:
+		return Or(randomPattern(rng, depth-1), randomPattern(rng, depth-1))
+	case 2:
+		return Optional(randomPattern(rng, depth-1))
+	case 3:
+		return ZeroOrMore(code)
+	case 4:
+		return OneOrMore(code)
+	case 5:
+		min, max := rng.Intn(3), -1
+		if r := rng.Intn(3); r > 0 {
+			max = min + r - 1
+		}
+		return Repeat(randomPattern(rng, depth-1), min, max)
+	case 6:
+		return RepeatLazy(randomPattern(rng, depth-1), 0, -1)
+	}
+	return Val(code)
+}
+
+func randomCodes(rng *rand.Rand) []int {
+	codes := make([]int, rng.Intn(8))
+	for i := range codes {
+		codes[i] = rng.Intn(5)
+	}
+	return codes
+}
+
+func TestDFAMatchesNFA(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 500 {
+		nfa := PatternToMatcher(randomPattern(rng, 3), randomPattern(rng, 2))
+		dfa, err := nfa.WithDFA()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nfa.HasDFA() || !dfa.HasDFA() {
+			t.Fatal("WithDFA modified the original matcher")
+		}
+		for range 20 {
+			parts := codesToCodeAndValue(randomCodes(rng))
+			want, _ := Match(nfa, parts)
+			got, _ := Match(dfa, parts)
+			if got != want {
+				t.Fatalf("%v: NFA matched %v, DFA matched %v. Matcher: %v", parts, want, got, nfa)
+			}
+		}
+	}
+}
+
+func TestClassifierDFAMatchesNFA(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for range 100 {
+		patterns := make([]Pattern, 1+rng.Intn(5))
+		for i := range patterns {
+			patterns[i] = randomPattern(rng, 3)
+		}
+		nfa := NewClassifier(patterns...)
+		dfa, err := nfa.WithDFA()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 20 {
+			parts := codesToCodeAndValue(randomCodes(rng))
+			want, _ := Classify(nfa, parts)
+			got, _ := Classify(dfa, parts)
+			if !slices.Equal(matchIndexes(got), matchIndexes(want)) {
+				t.Fatalf("%v: NFA matched %v, DFA matched %v", parts, matchIndexes(want), matchIndexes(got))
+			}
+		}
+	}
+}
+
+func TestDFAErrors(t *testing.T) {
+	var s string
+	for _, m := range []Matcher{
+		PatternToMatcher(CaptureString(0, &s)),
+		PatternToMatcher(ValWhere(0, ValueEquals("a"))),
+		PatternToMatcher(NotCode(0)),
+	} {
+		if _, err := m.WithDFA(); !errors.Is(err, ErrDFAUnsupported) {
+			t.Fatalf("expected ErrDFAUnsupported, got %v", err)
+		}
+	}
+
+	// The DFA has to remember which of the last 13 components were 1s.
+	blowup := PatternToMatcher(ZeroOrMore(Any), Val(1), Repeat(Val(Any), 12, 12))
+	if _, err := blowup.WithDFA(); !errors.Is(err, ErrDFATooLarge) {
+		t.Fatalf("expected ErrDFATooLarge, got %v", err)
+	}
+
+	if _, err := NewClassifier(CaptureString(0, &s)).WithDFA(); !errors.Is(err, ErrDFAUnsupported) {
+		t.Fatalf("expected ErrDFAUnsupported, got %v", err)
+	}
+}
+
+func TestDFAWithNamedCaptures(t *testing.T) {
+	m, err := PatternToMatcher(CaptureNamed("x", Val(0)), ZeroOrMore(1)).WithDFA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := []codeAndValue{{0, "a"}, {1, "b"}}
+	if found, _ := Match(m, parts); !found {
+		t.Fatal("failed to match")
+	}
+	caps, found, _ := MatchCaptures(m, parts)
+	if !found || caps.Get("x").Value() != "a" {
+		t.Fatalf("unexpected captures %v", caps)
+	}
+}
+
+func TestDFADoesNotAllocate(t *testing.T) {
+	m, err := PatternToMatcher(Or(Val(0), Val(1)), ZeroOrMore(Any), Val(2)).WithDFA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := codesToCodeAndValue([]int{0, 5, 6, 2})
+	allocs := testing.AllocsPerRun(100, func() {
+		if found, _ := Match(m, parts); !found {
+			t.Fatal("failed to match")
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+}
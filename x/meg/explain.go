@@ -0,0 +1,170 @@
+package meg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Diagnosis explains the result of matching components against a Matcher.
+type Diagnosis struct {
+	// Matched is whether the components matched, as reported by Match.
+	Matched bool
+	// Index is the index of the component where matching died. No path of
+	// the pattern accepts components[:Index+1], but some accept
+	// components[:Index] as a start. Index is len(components) if the
+	// components ended too early, or if they matched.
+	Index int
+	// Prefix is the length of the longest prefix of components matched by
+	// the whole pattern, or -1 if no prefix matches.
+	Prefix int
+	// Expected are the sorted codes that would have been accepted at Index.
+	Expected []int
+	// ExpectedAny is set if components of any code would have been accepted
+	// at Index, because of Any or NotCode.
+	ExpectedAny bool
+	// ExpectedEnd is set if the components could have ended at Index.
+	ExpectedEnd bool
+	// Got is components[Index], or nil if the components ended.
+	Got Matchable
+	// After is components[Index-1], or nil if matching died at the start.
+	After Matchable
+	// Err is the error returned by a capture func of the matching path.
+	Err error
+}
+
+// Rejected reports whether Got has an expected code, but was rejected by a
+// predicate.
+func (d Diagnosis) Rejected() bool {
+	return d.Got != nil && (d.ExpectedAny || slices.Contains(d.Expected, d.Got.Code()))
+}
+
+// Format describes the diagnosis, naming codes with name. For example:
+// "expected tcp or udp after ip4, got sctp".
+func (d Diagnosis) Format(name func(code int) string) string {
+	switch {
+	case d.Matched:
+		return "matched"
+	case d.Err != nil:
+		return d.Err.Error()
+	}
+	where := "at the start"
+	if d.After != nil {
+		where = "after " + name(d.After.Code())
+	}
+	if d.Rejected() {
+		return fmt.Sprintf("unexpected %s value %q %s", name(d.Got.Code()), d.Got.Value(), where)
+	}
+
+	var expected []string
+	for _, code := range d.Expected {
+		expected = append(expected, name(code))
+	}
+	if d.ExpectedAny {
+		expected = append(expected, "any component")
+	}
+	if d.ExpectedEnd {
+		expected = append(expected, "the end")
+	}
+	got := "the end"
+	if d.Got != nil {
+		got = name(d.Got.Code())
+	}
+	if len(expected) == 0 {
+		return fmt.Sprintf("pattern can't match %s, got %s", where, got)
+	}
+	return fmt.Sprintf("expected %s %s, got %s", joinOr(expected), where, got)
+}
+
+// String describes the diagnosis with numeric codes.
+func (d Diagnosis) String() string {
+	return d.Format(func(code int) string { return fmt.Sprintf("code %d", code) })
+}
+
+// joinOr joins items as "a, b or c".
+func joinOr(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
+
+// Explain matches components against matcher like Match, calling the capture
+// funcs of a match, and explains where and why matching failed.
+//
+// Explain always runs the NFA, so it's slower than Match. Use it once Match
+// failed, to report the failure.
+func Explain[T any, PT interface {
+	*T
+	Matchable
+}](matcher Matcher, components []T) Diagnosis {
+	states := matcher.states
+	preferExact := matcher.anyPriority == PreferExact
+	visitedBitSet := make([]uint64, (len(states)+63)/64)
+
+	var currentStates, nextStates statesAndCaptures
+	currentStates = appendState(currentStates, states, matcher.startIdx, nil, 0, 0, visitedBitSet, preferExact)
+
+	d := Diagnosis{Prefix: -1}
+	ic := 0
+	for ; ; ic++ {
+		for _, stateIndex := range currentStates.states {
+			if states[stateIndex].codeOrKind == done {
+				d.Prefix = ic
+				break
+			}
+		}
+		if ic == len(components) {
+			break
+		}
+
+		clear(visitedBitSet)
+		cPtr := PT(&components[ic])
+		for i, stateIndex := range currentStates.states {
+			s := &states[stateIndex]
+			if (s.codeOrKind == matchAny || (s.codeOrKind >= 0 && s.codeOrKind == cPtr.Code())) &&
+				(s.pred == nil || s.pred(cPtr)) {
+				cm := s.pushCapture(currentStates.captures[i], cPtr, false)
+				nextStates = appendState(nextStates, states, s.next, cm, 0, ic+1, visitedBitSet, preferExact)
+			}
+		}
+		if len(nextStates.states) == 0 {
+			d.Got = cPtr
+			break
+		}
+		currentStates, nextStates = nextStates, currentStates
+		nextStates.states = nextStates.states[:0]
+		nextStates.captures = nextStates.captures[:0]
+	}
+
+	d.Index = ic
+	if ic > 0 {
+		d.After = PT(&components[ic-1])
+	}
+	if d.Got == nil {
+		// The first done state is reached by the preferred path.
+		for i, stateIndex := range currentStates.states {
+			if states[stateIndex].codeOrKind == done {
+				if _, err := runCaptures(currentStates.captures[i], false, components); err != nil {
+					d.Err = err
+				} else {
+					d.Matched = true
+				}
+				return d
+			}
+		}
+	}
+
+	for _, stateIndex := range currentStates.states {
+		switch code := states[stateIndex].codeOrKind; {
+		case code == done:
+			d.ExpectedEnd = true
+		case code == matchAny:
+			d.ExpectedAny = true
+		case !slices.Contains(d.Expected, code):
+			d.Expected = append(d.Expected, code)
+		}
+	}
+	slices.Sort(d.Expected)
+	return d
+}
@@ -0,0 +1,128 @@
+package meg
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func testName(code int) string {
+	for name, c := range testCodes {
+		if c == code {
+			return name
+		}
+	}
+	return "?"
+}
+
+func TestExplain(t *testing.T) {
+	m := PatternToMatcher(
+		Or(Val(1), Val(2)),
+		Or(Val(3), Val(4)),
+		ZeroOrMore(5),
+		ValWhere(1, ValueEquals("x")),
+	)
+	for _, tc := range []struct {
+		parts    []codeAndValue
+		index    int
+		prefix   int
+		expected []int
+		text     string
+	}{
+		{
+			parts:    []codeAndValue{{1, ""}, {2, ""}},
+			index:    1,
+			prefix:   -1,
+			expected: []int{3, 4},
+			text:     "expected c or d after a, got b",
+		},
+		{
+			parts:    []codeAndValue{{3, ""}},
+			index:    0,
+			prefix:   -1,
+			expected: []int{1, 2},
+			text:     "expected a or b at the start, got c",
+		},
+		{
+			parts:    []codeAndValue{{1, ""}, {3, ""}, {5, ""}},
+			index:    3,
+			prefix:   -1,
+			expected: []int{1, 5},
+			text:     "expected a or p2p-x after p2p-x, got the end",
+		},
+		{
+			parts:    []codeAndValue{{1, ""}, {3, ""}, {1, "y"}},
+			index:    2,
+			prefix:   -1,
+			expected: []int{1, 5},
+			text:     `unexpected a value "y" after c`,
+		},
+	} {
+		d := Explain(m, tc.parts)
+		if d.Matched || d.Index != tc.index || d.Prefix != tc.prefix || !slices.Equal(d.Expected, tc.expected) {
+			t.Errorf("%v: unexpected diagnosis %+v", tc.parts, d)
+		}
+		if text := d.Format(testName); text != tc.text {
+			t.Errorf("%v: expected %q, got %q", tc.parts, tc.text, text)
+		}
+	}
+
+	d := Explain(m, []codeAndValue{{1, ""}, {3, ""}, {1, "x"}})
+	if !d.Matched || d.Index != 3 || d.Prefix != 3 || d.Format(testName) != "matched" {
+		t.Fatalf("unexpected diagnosis %+v", d)
+	}
+
+	// Matching too much reports the longest matching prefix.
+	d = Explain(PatternToMatcher(Val(1), Optional(Val(2))), codesToCodeAndValue([]int{1, 2, 3}))
+	if d.Matched || d.Index != 2 || d.Prefix != 2 || !d.ExpectedEnd {
+		t.Fatalf("unexpected diagnosis %+v", d)
+	}
+	if text := d.Format(testName); text != "expected the end after b, got c" {
+		t.Fatalf("unexpected text %q", text)
+	}
+
+	d = Explain(PatternToMatcher(Val(1), Val(Any)), codesToCodeAndValue([]int{1}))
+	if !d.ExpectedAny || d.String() != "expected any component after code 1, got the end" {
+		t.Fatalf("unexpected diagnosis %q", d)
+	}
+}
+
+func TestExplainCaptureError(t *testing.T) {
+	errBoom := errors.New("boom")
+	m := PatternToMatcher(CaptureWithF(1, func(Matchable) error { return errBoom }))
+	d := Explain(m, codesToCodeAndValue([]int{1}))
+	if d.Matched || !errors.Is(d.Err, errBoom) || d.Format(testName) != "boom" {
+		t.Fatalf("unexpected diagnosis %+v", d)
+	}
+}
+
+func TestExplainAgreesWithMatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for range 500 {
+		m := PatternToMatcher(randomPattern(rng, 3), randomPattern(rng, 2))
+		for range 20 {
+			parts := codesToCodeAndValue(randomCodes(rng))
+			found, _ := Match(m, parts)
+			d := Explain(m, parts)
+			if d.Matched != found {
+				t.Fatalf("%v: Match returned %v, Explain %+v. Matcher: %v", parts, found, d, m)
+			}
+			if found {
+				continue
+			}
+			if prefix, ok, _ := MatchPrefix(m, parts); (ok && prefix != d.Prefix) || (!ok && d.Prefix != -1) {
+				t.Fatalf("%v: MatchPrefix returned %d, Explain %+v. Matcher: %v", parts, prefix, d, m)
+			}
+			// The pattern accepts the components up to Index, but not after.
+			if d.Index < len(parts) {
+				if found, _ := Match(m, parts[:d.Index+1]); found {
+					t.Fatalf("%v: matching didn't die at %d", parts, d.Index)
+				}
+				if d.ExpectedAny || slices.Contains(d.Expected, parts[d.Index].code) {
+					t.Fatalf("%v: component %d was expected: %+v", parts, d.Index, d)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package meg
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrUnstructured is returned by Matcher.Text for state graphs that weren't
+// built by the combinators of this package, and have no textual form.
+var ErrUnstructured = errors.New("matcher has no textual form")
+
+// codeName returns the name of code, or "*" for Any. A nil name uses the
+// decimal code.
+func codeName(name func(code int) string, code int) string {
+	switch {
+	case code == matchAny:
+		return "*"
+	case name == nil:
+		return strconv.Itoa(code)
+	}
+	return name(code)
+}
+
+// DOT returns the state graph of the matcher in the Graphviz DOT language,
+// naming codes with name. Splits are drawn as diamonds, with their preferred
+// branch drawn solid and the other one dashed. The bounds of group captures
+// are drawn as boxes. States with predicates or capture funcs are marked with
+// "(where)" and "(capture)".
+func (s Matcher) DOT(name func(code int) string) string {
+	var b strings.Builder
+	b.WriteString("digraph meg {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=circle];\n")
+	b.WriteString("\tstart [shape=point];\n")
+	fmt.Fprintf(&b, "\tstart -> s%d;\n", s.startIdx)
+	for i, state := range s.states {
+		switch {
+		case state.codeOrKind == done:
+			fmt.Fprintf(&b, "\ts%d [shape=doublecircle, label=\"done\"];\n", i)
+		case state.codeOrKind == groupStart, state.codeOrKind == groupEnd:
+			label := "("
+			if state.codeOrKind == groupEnd {
+				label = ")"
+			}
+			fmt.Fprintf(&b, "\ts%d [shape=box, label=%q];\n", i, label)
+			fmt.Fprintf(&b, "\ts%d -> s%d;\n", i, state.next)
+		case state.codeOrKind < done:
+			fmt.Fprintf(&b, "\ts%d [shape=diamond, label=\"\"];\n", i)
+			fmt.Fprintf(&b, "\ts%d -> s%d;\n", i, state.next)
+			fmt.Fprintf(&b, "\ts%d -> s%d [style=dashed];\n", i, decodeSplitIdx(state.codeOrKind))
+		default:
+			label := codeName(name, state.codeOrKind)
+			if state.name != "" {
+				label += ":" + state.name
+			}
+			if state.pred != nil {
+				label += " (where)"
+			}
+			if state.capture != nil {
+				label += " (capture)"
+			}
+			fmt.Fprintf(&b, "\ts%d [label=%s];\n", i, strconv.Quote(label))
+			fmt.Fprintf(&b, "\ts%d -> s%d;\n", i, state.next)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Text renders the matcher in the notation of Parse, such as "/a/{b|c}/d*",
+// naming codes with name. Parsing the result gives an equivalent matcher,
+// although repetitions and alternatives may be written differently than in
+// the original pattern.
+//
+// Capture funcs and group captures are left out. The codes rejected by NotCode
+// are rendered as "!a" or "!{a|b}". Other predicates, such as those of
+// ValWhere, can't be written in the notation, and are rendered as "<where>"
+// after the code. Text returns ErrUnstructured for matchers whose
+// states weren't built by this package's patterns.
+func (s Matcher) Text(name func(code int) string) (string, error) {
+	r := renderer{states: s.states, name: name, ipdom: postDominators(s.states)}
+	terms, err := r.seq(s.startIdx, -1, 0)
+	if err != nil {
+		return "", err
+	}
+	return seqText(terms, true), nil
+}
+
+// term is a term of the pattern notation: an atom and a quantifier.
+type term struct {
+	atom     string
+	min, max int
+	lazy     bool
+}
+
+func (t term) String() string {
+	var q string
+	switch {
+	case t.min == 1 && t.max == 1:
+		return t.atom
+	case t.min == 0 && t.max == 1:
+		q = "?"
+	case t.min == 0 && t.max < 0:
+		q = "*"
+	case t.min == 1 && t.max < 0:
+		q = "+"
+	case t.min == t.max:
+		q = fmt.Sprintf("{%d}", t.min)
+	case t.max < 0:
+		q = fmt.Sprintf("{%d,}", t.min)
+	default:
+		q = fmt.Sprintf("{%d,%d}", t.min, t.max)
+	}
+	if t.lazy {
+		q += "?"
+	}
+	return t.atom + q
+}
+
+// notText renders the codes rejected by NotCode as "!a" or "!{a|b}".
+func notText(name func(code int) string, codes []int) string {
+	if len(codes) == 1 {
+		return "!" + codeName(name, codes[0])
+	}
+	names := make([]string, len(codes))
+	for i, code := range codes {
+		names[i] = codeName(name, code)
+	}
+	return "!{" + strings.Join(names, "|") + "}"
+}
+
+// seqText joins terms with slashes, with a leading slash at the top level.
+func seqText(terms []term, top bool) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t.String()
+	}
+	text := strings.Join(parts, "/")
+	if top && text != "" {
+		text = "/" + text
+	}
+	return text
+}
+
+// group returns terms as a single atom.
+func group(terms []term) string {
+	if len(terms) == 1 && terms[0].min == 1 && terms[0].max == 1 {
+		return terms[0].atom
+	}
+	return "{" + seqText(terms, false) + "}"
+}
+
+// appendTerm appends t to terms, merging it into the last term when both
+// repeat the same atom, as in a/a* = a+ and a?/a? = a{0,2}.
+func appendTerm(terms []term, t term) []term {
+	if n := len(terms); n > 0 {
+		last := &terms[n-1]
+		lastExact, exact := last.min == last.max, t.min == t.max
+		if last.atom == t.atom && (lastExact || exact || last.lazy == t.lazy) {
+			if lastExact {
+				last.lazy = t.lazy
+			}
+			last.min += t.min
+			if last.max < 0 || t.max < 0 {
+				last.max = -1
+			} else {
+				last.max += t.max
+			}
+			return terms
+		}
+	}
+	return append(terms, t)
+}
+
+// quantify returns body repeated between min and max times.
+func quantify(body []term, min, max int, lazy bool) term {
+	if len(body) == 1 && body[0].min == 1 && body[0].max == 1 {
+		return term{atom: body[0].atom, min: min, max: max, lazy: lazy}
+	}
+	// {p{1,n}}? is p{0,n}.
+	if min == 0 && max == 1 && len(body) == 1 && body[0].min == 1 && body[0].lazy == lazy {
+		t := body[0]
+		t.min = 0
+		return t
+	}
+	return term{atom: group(body), min: min, max: max, lazy: lazy}
+}
+
+type renderer struct {
+	states []MatchState
+	name   func(code int) string
+	// ipdom holds the immediate post-dominator of each state: the first
+	// state through which all paths from the state to done go.
+	ipdom []int
+
+	// visited, epoch and stack are reused by reaches.
+	visited []int
+	epoch   int
+	stack   []int
+}
+
+// maxRenderDepth bounds the nesting of groups, so that graphs that aren't
+// structured can't recurse forever.
+const maxRenderDepth = 1000
+
+// seq renders the paths from state from to state to, or to done if to is
+// negative.
+func (r *renderer) seq(from, to, depth int) ([]term, error) {
+	if depth > maxRenderDepth {
+		return nil, ErrUnstructured
+	}
+	var terms []term
+	for steps := 0; from != to; steps++ {
+		if from < 0 || from >= len(r.states) || steps > len(r.states) {
+			return nil, ErrUnstructured
+		}
+		s := &r.states[from]
+		switch {
+		case s.codeOrKind == done:
+			if to >= 0 {
+				return nil, ErrUnstructured
+			}
+			return terms, nil
+		case s.codeOrKind >= matchAny:
+			atom := codeName(r.name, s.codeOrKind)
+			switch {
+			case len(s.notCodes) > 0:
+				atom = notText(r.name, s.notCodes)
+			case s.pred != nil:
+				atom += "<where>"
+			}
+			if s.name != "" {
+				atom += ":" + s.name
+			}
+			terms = appendTerm(terms, term{atom: atom, min: 1, max: 1})
+			from = s.next
+		case s.codeOrKind == groupStart || s.codeOrKind == groupEnd:
+			from = s.next
+		default:
+			join := r.ipdom[from]
+			if join < 0 {
+				return nil, ErrUnstructured
+			}
+			t, body, ok, err := r.split(from, join, depth)
+			if err != nil {
+				return nil, err
+			}
+			if ok && len(body) > 1 {
+				// Fold copies of a loop's body before the loop, as in
+				// {a/b}/{a/b}* = {a/b}+.
+				for n := len(terms) - len(body); n >= 0 && slices.Equal(terms[n:], body); n -= len(body) {
+					terms = terms[:n]
+					t.min++
+				}
+			}
+			if ok {
+				terms = appendTerm(terms, t)
+			}
+			from = join
+		}
+	}
+	return terms, nil
+}
+
+// split renders the paths from split state idx to join, its immediate
+// post-dominator, as a single term. body is the body of a loop, which is
+// repeated by the term. ok is false if the paths are all empty.
+func (r *renderer) split(idx, join, depth int) (t term, body []term, ok bool, err error) {
+	s := &r.states[idx]
+	branches := [2]int{s.next, decodeSplitIdx(s.codeOrKind)}
+	for i, b := range branches {
+		if b != join && r.reaches(b, idx, join) {
+			// A loop: the body comes back to the split, and the other
+			// branch exits to join.
+			if branches[1-i] != join {
+				return term{}, nil, false, ErrUnstructured
+			}
+			body, err := r.seq(b, idx, depth+1)
+			if err != nil || len(body) == 0 {
+				return term{}, nil, false, err
+			}
+			return quantify(body, 0, -1, i == 1), body, true, nil
+		}
+	}
+
+	var alts [][]term
+	optional, lazy := false, false
+	if err := r.alternatives(idx, join, depth, &alts, &optional, &lazy); err != nil {
+		return term{}, nil, false, err
+	}
+	switch len(alts) {
+	case 0:
+		return term{}, nil, false, nil
+	case 1:
+		body = alts[0]
+	default:
+		texts := make([]string, len(alts))
+		for i, alt := range alts {
+			texts[i] = seqText(alt, false)
+		}
+		body = []term{{atom: "{" + strings.Join(texts, "|") + "}", min: 1, max: 1}}
+	}
+	if !optional {
+		return quantify(body, 1, 1, false), nil, true, nil
+	}
+	return quantify(body, 0, 1, lazy), nil, true, nil
+}
+
+// alternatives collects the branches of the split state idx and of the
+// splits it chains to, as built by Or. An empty branch sets optional, and
+// lazy if it is the first one.
+func (r *renderer) alternatives(idx, join, depth int, alts *[][]term, optional, lazy *bool) error {
+	s := &r.states[idx]
+	for _, b := range [2]int{s.next, decodeSplitIdx(s.codeOrKind)} {
+		switch {
+		case r.states[b].codeOrKind < done && r.ipdom[b] == join && !r.isLoop(b, join):
+			if err := r.alternatives(b, join, depth, alts, optional, lazy); err != nil {
+				return err
+			}
+			continue
+		case b != join:
+			alt, err := r.seq(b, join, depth+1)
+			if err != nil {
+				return err
+			}
+			if len(alt) > 0 {
+				*alts = append(*alts, alt)
+				continue
+			}
+		}
+		// An empty branch.
+		if !*optional && len(*alts) == 0 {
+			*lazy = true
+		}
+		*optional = true
+	}
+	return nil
+}
+
+func (r *renderer) isLoop(idx, join int) bool {
+	s := &r.states[idx]
+	for _, b := range [2]int{s.next, decodeSplitIdx(s.codeOrKind)} {
+		if b != join && r.reaches(b, idx, join) {
+			return true
+		}
+	}
+	return false
+}
+
+// reaches reports whether target is reachable from from without going
+// through avoid. The visited marks and the stack are kept across calls, and
+// marks from earlier calls are told apart by the epoch.
+func (r *renderer) reaches(from, target, avoid int) bool {
+	if r.visited == nil {
+		r.visited = make([]int, len(r.states))
+	}
+	r.epoch++
+	r.stack = append(r.stack[:0], from)
+	for len(r.stack) > 0 {
+		idx := r.stack[len(r.stack)-1]
+		r.stack = r.stack[:len(r.stack)-1]
+		if idx == target {
+			return true
+		}
+		if idx == avoid || idx < 0 || idx >= len(r.states) || r.visited[idx] == r.epoch {
+			continue
+		}
+		r.visited[idx] = r.epoch
+		r.stack = appendSuccessors(r.stack, &r.states[idx])
+	}
+	return false
+}
+
+// appendSuccessors appends the states that can follow s to dst.
+func appendSuccessors(dst []int, s *MatchState) []int {
+	switch {
+	case s.codeOrKind == done:
+		return dst
+	case s.codeOrKind < done:
+		return append(dst, s.next, decodeSplitIdx(s.codeOrKind))
+	}
+	// Match states and group marks.
+	return append(dst, s.next)
+}
+
+// postDominators returns the immediate post-dominator of each state, or -1
+// for done states and states that don't reach a done state.
+func postDominators(states []MatchState) []int {
+	n := len(states)
+	// The extra bits past n stay set for states that don't reach done.
+	words := n/64 + 1
+	// pdom[i] is the set of states through which all paths from i to a done
+	// state go, including i.
+	pdom := make([][]uint64, n)
+	for i := range states {
+		pdom[i] = make([]uint64, words)
+		if states[i].codeOrKind == done {
+			pdom[i][i/64] |= 1 << (i % 64)
+			continue
+		}
+		for w := range pdom[i] {
+			pdom[i][w] = ^uint64(0)
+		}
+	}
+	tmp := make([]uint64, words)
+	var succ []int
+	for changed := true; changed; {
+		changed = false
+		for i := range states {
+			succ = appendSuccessors(succ[:0], &states[i])
+			if len(succ) == 0 {
+				continue
+			}
+			for w := range tmp {
+				tmp[w] = ^uint64(0)
+			}
+			for _, j := range succ {
+				if j < 0 || j >= n {
+					clear(tmp)
+					break
+				}
+				for w := range tmp {
+					tmp[w] &= pdom[j][w]
+				}
+			}
+			tmp[i/64] |= 1 << (i % 64)
+			for w := range tmp {
+				if tmp[w] != pdom[i][w] {
+					copy(pdom[i], tmp)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	count := func(set []uint64) int {
+		c := 0
+		for _, w := range set {
+			c += bits.OnesCount64(w)
+		}
+		return c
+	}
+	ipdom := make([]int, n)
+	for i := range states {
+		ipdom[i] = -1
+		if pdom[i][words-1]>>(n%64) != 0 {
+			continue
+		}
+		size := count(pdom[i])
+		// The immediate post-dominator is the strict post-dominator that is
+		// post-dominated by all the others.
+		for j := range states {
+			if j != i && pdom[i][j/64]&(1<<(j%64)) != 0 && count(pdom[j]) == size-1 {
+				ipdom[i] = j
+				break
+			}
+		}
+	}
+	return ipdom
+}
@@ -0,0 +1,149 @@
+package meg
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMatcherText(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		text    string
+	}{
+		{"", ""},
+		{"/a/b", "/a/b"},
+		{"/a/{b|c}/d*", "/a/{b|c}/d*"},
+		{"/{a|b|c}?/d+", "/{a|b|c}?/d+"},
+		{"/a/{b/c|d}??", "/a/{b/c|d}??"},
+		{"/{a/b}*?/c", "/{a/b}*?/c"},
+		{"/a{3}/b{2,}/c{0,3}/d{1,2}", "/a{3}/b{2,}/c{0,3}/d{1,2}"},
+		{"/{a?}*/b:x+/*", "/{a?}*/b:x+/*"},
+		{"/{a|b}:x/c", "/{a:x|b:x}/c"},
+		{"/{{a|b}*/c}+", "/{{a|b}*/c}+"},
+		{"/!a", "/!a"},
+		{"/!{a|b}:x/c?", "/!{a|b}:x/c?"},
+	} {
+		e, err := Parse(tc.pattern, testLookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, err := e.Matcher(nil).Text(testName)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.pattern, err)
+		}
+		if text != tc.text {
+			t.Errorf("%q: expected %q, got %q", tc.pattern, tc.text, text)
+		}
+	}
+
+	text, err := PatternToMatcher(Val(1), ZeroOrMore(Any)).Text(nil)
+	if err != nil || text != "/1/**" {
+		t.Fatalf("unexpected text %q: %v", text, err)
+	}
+
+	// Group captures are left out.
+	var span Span
+	text, err = PatternToMatcher(ZeroOrMoreOf(CaptureGroupSpan(Cat(Val(1), Val(2)), &span)), Val(3)).Text(testName)
+	if err != nil || text != "/{a/b}*/c" {
+		t.Fatalf("unexpected text %q: %v", text, err)
+	}
+}
+
+func TestMatcherTextRoundTrip(t *testing.T) {
+	name := func(code int) string { return "c" + strconv.Itoa(code) }
+	lookup := func(s string) (int, bool) {
+		code, err := strconv.Atoi(strings.TrimPrefix(s, "c"))
+		return code, err == nil && strings.HasPrefix(s, "c")
+	}
+	rng := rand.New(rand.NewSource(4))
+	for range 500 {
+		m := PatternToMatcher(randomPattern(rng, 3), randomPattern(rng, 2))
+		text, err := m.Text(name)
+		if err != nil {
+			t.Fatalf("%v: %v", m, err)
+		}
+		e, err := Parse(text, lookup)
+		if err != nil {
+			t.Fatalf("%q: %v", text, err)
+		}
+		parsed := e.Matcher(nil)
+		for range 20 {
+			parts := codesToCodeAndValue(randomCodes(rng))
+			want, _ := Match(m, parts)
+			if got, _ := Match(parsed, parts); got != want {
+				t.Fatalf("%v: %q matched %v, original matched %v. Matcher: %v", parts, text, got, want, m)
+			}
+		}
+	}
+}
+
+func TestMatcherTextRoundTripNegation(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for _, pattern := range []string{
+		"/!a",
+		"/!{a|b}*/c",
+		"/{!a|b}+/!{b|c}?",
+		"/a/{!c/b}*?/!{a|b|c}:x",
+	} {
+		e, err := Parse(pattern, testLookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := e.Matcher(nil)
+		text, err := m.Text(testName)
+		if err != nil {
+			t.Fatalf("%q: %v", pattern, err)
+		}
+		again, err := Parse(text, testLookup)
+		if err != nil {
+			t.Fatalf("%q rendered as %q: %v", pattern, text, err)
+		}
+		parsed := again.Matcher(nil)
+		for range 200 {
+			parts := codesToCodeAndValue(randomCodes(rng))
+			want, _ := Match(m, parts)
+			if got, _ := Match(parsed, parts); got != want {
+				t.Fatalf("%v: %q matched %v, %q matched %v", parts, text, got, pattern, want)
+			}
+		}
+	}
+
+	text, err := PatternToMatcher(NotCode(1, 2), NotCode()).Text(testName)
+	if err != nil || text != "/!{a|b}/*" {
+		t.Fatalf("unexpected text %q: %v", text, err)
+	}
+}
+
+func TestMatcherTextUnstructured(t *testing.T) {
+	// Two loops entering each other's bodies.
+	m := Matcher{
+		states: []MatchState{
+			{codeOrKind: done},
+			{codeOrKind: 1, next: 2},
+			{codeOrKind: encodeSplitIdx(0), next: 3},
+			{codeOrKind: 2, next: 4},
+			{codeOrKind: encodeSplitIdx(1), next: 0},
+			{codeOrKind: encodeSplitIdx(3), next: 1},
+		},
+		startIdx: 5,
+	}
+	if _, err := m.Text(nil); !errors.Is(err, ErrUnstructured) {
+		t.Fatalf("expected ErrUnstructured, got %v", err)
+	}
+}
+
+func TestMatcherDOT(t *testing.T) {
+	e, err := Parse("/a:x/{b|c}*", testLookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dot := e.Matcher(nil).DOT(testName)
+	for _, want := range []string{"digraph meg {", `[label="a:x"]`, `[label="b"]`, "shape=diamond", "shape=doublecircle", "style=dashed"} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("expected %q in:\n%s", want, dot)
+		}
+	}
+}
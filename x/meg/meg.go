@@ -13,6 +13,11 @@ type stateKind = int
 
 const (
 	matchAny stateKind = (iota * -1) - 1
+	// groupStart and groupEnd states mark the bounds of a group capture.
+	// They don't match a component, and record the position at which they
+	// are passed.
+	groupStart
+	groupEnd
 	// done MUST be the last stateKind in this list.
 	// Anything that is less than done is a split index
 	done
@@ -21,6 +26,17 @@ const (
 // MatchState is the Thompson NFA for a regular expression.
 type MatchState struct {
 	capture CaptureFunc
+	// pred, if set, must also accept the component for a match.
+	pred Predicate
+	// notCodes are the codes rejected by pred, if it was built by NotCode or
+	// by "!" in a parsed pattern, so that Text can render it.
+	notCodes []int
+	// name, if set, records the matched component in the Captures returned
+	// by MatchCaptures.
+	name string
+	// group is the func of a group capture, for groupStart and groupEnd
+	// states.
+	group groupFunc
 	// next is is the index of the next state. in the MatchState array.
 	next int
 	// If codeOrKind is negative, it is a kind.
@@ -31,16 +47,36 @@ type MatchState struct {
 
 type CaptureFunc func(Matchable) error
 
-// capture is a linked list of capture funcs with values.
+// GroupFunc is called with the span of the components matched by a group
+// capture. The span indexes the components passed to Match, or to the other
+// matching functions, so that the caller can slice them without copying.
+type GroupFunc func(span Span) error
+
+// groupFunc is the func of a group capture as stored in the states. It gets
+// the []T slice being matched, so that CaptureGroupSlice can slice it.
+type groupFunc func(components any, span Span) error
+
+// Predicate reports whether a component is accepted by a match state.
+type Predicate func(Matchable) bool
+
+// capture is a linked list of capture funcs and names with values, and of
+// the group marks passed by a thread.
 type capture struct {
 	f    CaptureFunc
+	name string
 	v    Matchable
-	prev *capture
+	// mark is groupStart or groupEnd for group marks, which record pos.
+	mark  stateKind
+	group groupFunc
+	pos   int
+	prev  *capture
 }
 
 type statesAndCaptures struct {
 	states   []int
 	captures []*capture
+	// starts are the positions at which the threads started matching.
+	starts []int
 }
 
 func (s MatchState) String() string {
@@ -50,6 +86,15 @@ func (s MatchState) String() string {
 	if s.codeOrKind < done {
 		return fmt.Sprintf("split{left: %d, right: %d}", s.next, decodeSplitIdx(s.codeOrKind))
 	}
+	if s.codeOrKind == groupStart {
+		return fmt.Sprintf("groupStart{next: %d}", s.next)
+	}
+	if s.codeOrKind == groupEnd {
+		return fmt.Sprintf("groupEnd{next: %d}", s.next)
+	}
+	if s.pred != nil {
+		return fmt.Sprintf("matchWhere{code: %d, next: %d}", s.codeOrKind, s.next)
+	}
 	return fmt.Sprintf("match{code: %d, next: %d}", s.codeOrKind, s.next)
 }
 
@@ -77,8 +122,75 @@ func Match[T any, PT interface {
 	*T
 	Matchable
 }](matcher Matcher, components []T) (bool, error) {
+	if matcher.dfa != nil {
+		state := dfaRun[T, PT](matcher.dfa, components)
+		return state >= 0 && len(matcher.dfa.done[state]) > 0, nil
+	}
+	_, ok, err := match[T, PT](matcher, components, false)
+	return ok, err
+}
+
+// Captures holds the components recorded by CaptureNamed patterns during a
+// match, by name and in order.
+type Captures map[string][]Matchable
+
+// Get returns the first component captured under name, or nil.
+func (c Captures) Get(name string) Matchable {
+	if vs := c[name]; len(vs) > 0 {
+		return vs[0]
+	}
+	return nil
+}
+
+// Values returns the string values of the components captured under name.
+func (c Captures) Values(name string) []string {
+	vs := c[name]
+	if len(vs) == 0 {
+		return nil
+	}
+	out := make([]string, len(vs))
+	for i, v := range vs {
+		out[i] = v.Value()
+	}
+	return out
+}
+
+// MatchCaptures is like Match, and also returns the components recorded by
+// CaptureNamed patterns. The captured values point into components.
+//
+// Named captures are stored in the returned Captures rather than in variables
+// shared by all matches, so a Matcher that only uses named captures can be
+// built once and used from many goroutines. Captures is nil if nothing was
+// captured.
+func MatchCaptures[T any, PT interface {
+	*T
+	Matchable
+}](matcher Matcher, components []T) (Captures, bool, error) {
+	return match[T, PT](matcher, components, true)
+}
+
+func match[T any, PT interface {
+	*T
+	Matchable
+}](matcher Matcher, components []T, wantNamed bool) (Captures, bool, error) {
+	_, named, ok, err := search[T, PT](matcher, components, AnchorBoth, wantNamed)
+	return named, ok, err
+}
+
+// search runs the NFA over components and returns the leftmost-longest match
+// allowed by anchor.
+//
+// Without AnchorStart, a new thread is started at every position until a
+// match is found. Threads remember the position they started at, and threads
+// that started earlier have priority, so the first thread to reach the done
+// state at a position is the leftmost one.
+func search[T any, PT interface {
+	*T
+	Matchable
+}](matcher Matcher, components []T, anchor Anchor, wantNamed bool) (Span, Captures, bool, error) {
 	states := matcher.states
 	startStateIdx := matcher.startIdx
+	preferExact := matcher.anyPriority == PreferExact
 
 	// Fast case for a small number of states (<128)
 	// Avoids allocation of a slice for the visitedBitSet.
@@ -96,72 +208,152 @@ func Match[T any, PT interface {
 		states:   make([]int, 0, 16),
 		captures: make([]*capture, 0, 16),
 	}
+	// Anchored threads all start at 0, so there is no need to track starts.
+	trackStarts := anchor&AnchorStart == 0
+	if trackStarts {
+		currentStates.starts = make([]int, 0, 16)
+		nextStates.starts = make([]int, 0, 16)
+	}
 
-	currentStates = appendState(currentStates, states, startStateIdx, nil, visitedBitSet)
+	currentStates = appendState(currentStates, states, startStateIdx, nil, 0, 0, visitedBitSet, preferExact)
 
-	for ic := range len(components) {
-		clear(visitedBitSet)
-		if len(currentStates.states) == 0 {
-			return false, nil
+	var (
+		found   bool
+		best    Span
+		bestCap *capture
+	)
+	for ic := 0; ; ic++ {
+		if anchor&AnchorEnd == 0 || ic == len(components) {
+			for i, stateIndex := range currentStates.states {
+				if states[stateIndex].codeOrKind != done {
+					continue
+				}
+				start := 0
+				if trackStarts {
+					start = currentStates.starts[i]
+				}
+				if !found || start < best.Start || (start == best.Start && ic > best.End) {
+					found = true
+					best = Span{Start: start, End: ic}
+					bestCap = currentStates.captures[i]
+				}
+			}
 		}
+		if ic == len(components) {
+			break
+		}
+		if len(currentStates.states) == 0 && (anchor&AnchorStart != 0 || found) {
+			break
+		}
+
+		clear(visitedBitSet)
 		for i, stateIndex := range currentStates.states {
+			start := 0
+			if trackStarts {
+				start = currentStates.starts[i]
+				if found && start > best.Start {
+					// A match that starts earlier was already found.
+					continue
+				}
+			}
 			s := &states[stateIndex]
 			cPtr := PT(&components[ic])
-			if s.codeOrKind == matchAny || (s.codeOrKind >= 0 && s.codeOrKind == cPtr.Code()) {
-				cm := currentStates.captures[i]
-				if s.capture != nil {
-					next := &capture{
-						f: s.capture,
-						v: cPtr,
-					}
-					if cm == nil {
-						cm = next
-					} else {
-						next.prev = cm
-						cm = next
-					}
-					currentStates.captures[i] = cm
-				}
-				nextStates = appendState(nextStates, states, s.next, cm, visitedBitSet)
+			if (s.codeOrKind == matchAny || (s.codeOrKind >= 0 && s.codeOrKind == cPtr.Code())) &&
+				(s.pred == nil || s.pred(cPtr)) {
+				cm := s.pushCapture(currentStates.captures[i], cPtr, wantNamed)
+				currentStates.captures[i] = cm
+				nextStates = appendState(nextStates, states, s.next, cm, start, ic+1, visitedBitSet, preferExact)
 			}
 		}
+		if trackStarts && !found {
+			// Start a lower priority thread at the next position.
+			nextStates = appendState(nextStates, states, startStateIdx, nil, ic+1, ic+1, visitedBitSet, preferExact)
+		}
 		currentStates, nextStates = nextStates, currentStates
 		nextStates.states = nextStates.states[:0]
 		nextStates.captures = nextStates.captures[:0]
+		if trackStarts {
+			nextStates.starts = nextStates.starts[:0]
+		}
 	}
 
-	for i, stateIndex := range currentStates.states {
-		s := &states[stateIndex]
-		if s.codeOrKind == done {
+	if !found {
+		return Span{}, nil, false, nil
+	}
+	// We found a complete path. Run the captures now
+	named, err := runCaptures(bestCap, wantNamed, components)
+	if err != nil {
+		return Span{}, nil, false, err
+	}
+	return best, named, true, nil
+}
 
-			// We found a complete path. Run the captures now
-			c := currentStates.captures[i]
+// pushCapture returns the capture list cm of a thread after s matched c.
+func (s *MatchState) pushCapture(cm *capture, c Matchable, wantNamed bool) *capture {
+	if s.capture == nil && (!wantNamed || s.name == "") {
+		return cm
+	}
+	return &capture{
+		f:    s.capture,
+		name: s.name,
+		v:    c,
+		prev: cm,
+	}
+}
 
-			// Flip the order of the captures because we see captures from right
-			// to left, but users expect them left to right.
-			type captureWithVal struct {
-				f CaptureFunc
-				v Matchable
+// runCaptures calls the capture funcs and group funcs of a complete path over
+// components, and collects its named captures if wantNamed is set.
+func runCaptures[T any](c *capture, wantNamed bool, components []T) (Captures, error) {
+	// Flip the order of the captures because we see captures from right
+	// to left, but users expect them left to right.
+	reversedCaptures := make([]*capture, 0, 16)
+	for c != nil {
+		reversedCaptures = append(reversedCaptures, c)
+		c = c.prev
+	}
+	var named Captures
+	// groupStarts holds the positions of the groups that are open. Groups
+	// nest, so the innermost one ends first.
+	var groupStarts []int
+	// boxed is components as passed to group funcs, boxed once.
+	var boxed any
+	for i := len(reversedCaptures) - 1; i >= 0; i-- {
+		c := reversedCaptures[i]
+		switch c.mark {
+		case groupStart:
+			groupStarts = append(groupStarts, c.pos)
+			continue
+		case groupEnd:
+			start := groupStarts[len(groupStarts)-1]
+			groupStarts = groupStarts[:len(groupStarts)-1]
+			if boxed == nil {
+				boxed = components
 			}
-			reversedCaptures := make([]captureWithVal, 0, 16)
-			for c != nil {
-				reversedCaptures = append(reversedCaptures, captureWithVal{c.f, c.v})
-				c = c.prev
+			if err := c.group(boxed, Span{Start: start, End: c.pos}); err != nil {
+				return nil, err
 			}
-			for i := len(reversedCaptures) - 1; i >= 0; i-- {
-				if err := reversedCaptures[i].f(reversedCaptures[i].v); err != nil {
-					return false, err
-				}
+			continue
+		}
+		if c.f != nil {
+			if err := c.f(c.v); err != nil {
+				return nil, err
+			}
+		}
+		if wantNamed && c.name != "" {
+			if named == nil {
+				named = make(Captures)
 			}
-			return true, nil
+			named[c.name] = append(named[c.name], c.v)
 		}
 	}
-	return false, nil
+	return named, nil
 }
 
 // appendState is a non-recursive way of appending states to statesAndCaptures.
-// If a state is a split, both branches are appended to statesAndCaptures.
-func appendState(arr statesAndCaptures, states []MatchState, stateIndex int, c *capture, visitedBitSet []uint64) statesAndCaptures {
+// If a state is a split, both branches are appended to statesAndCaptures, the
+// preferred branch first. Group marks are followed, and recorded at pos, the
+// number of components read so far. See AnyPriority for preferExact.
+func appendState(arr statesAndCaptures, states []MatchState, stateIndex int, c *capture, start, pos int, visitedBitSet []uint64, preferExact bool) statesAndCaptures {
 	// Local struct to hold state index and the associated capture pointer.
 	type task struct {
 		idx int
@@ -197,9 +389,10 @@ func appendState(arr statesAndCaptures, states []MatchState, stateIndex int, c *
 			// Get the second branch from the split.
 			splitIdx := decodeSplitIdx(s.codeOrKind)
 
-			// Check if the next branch is a `matchAny`. If it is, we want to
-			// deprioritize it to allow for less greedy Any behavior.
-			if states[s.next].codeOrKind == matchAny {
+			// Check if the next branch is a `matchAny`, possibly after group
+			// marks. If it is, we want to deprioritize it to allow for less
+			// greedy Any behavior.
+			if preferExact && firstMatchState(states, s.next).codeOrKind == matchAny {
 				// We want to process the non-matchAny first, so we push the s.next branch first
 				stack = append(stack, task{s.next, t.cap})
 				stack = append(stack, task{splitIdx, t.cap})
@@ -208,15 +401,35 @@ func appendState(arr statesAndCaptures, states []MatchState, stateIndex int, c *
 				stack = append(stack, task{splitIdx, t.cap})
 				stack = append(stack, task{s.next, t.cap})
 			}
+		} else if s.codeOrKind == groupStart || s.codeOrKind == groupEnd {
+			stack = append(stack, task{s.next, &capture{
+				mark:  s.codeOrKind,
+				group: s.group,
+				pos:   pos,
+				prev:  t.cap,
+			}})
 		} else {
 			// Otherwise, it's a valid final state -- append it.
 			arr.states = append(arr.states, t.idx)
 			arr.captures = append(arr.captures, t.cap)
+			if arr.starts != nil {
+				arr.starts = append(arr.starts, start)
+			}
 		}
 	}
 	return arr
 }
 
+// firstMatchState returns the state at idx, or the state after the group
+// marks that start at idx.
+func firstMatchState(states []MatchState, idx int) *MatchState {
+	s := &states[idx]
+	for s.codeOrKind == groupStart || s.codeOrKind == groupEnd {
+		s = &states[s.next]
+	}
+	return s
+}
+
 const splitIdxOffset = -(done - 1)
 
 func encodeSplitIdx(codeOrKind int) int {
@@ -1,6 +1,7 @@
 package meg
 
 import (
+	"errors"
 	"regexp"
 	"slices"
 	"testing"
@@ -422,3 +423,289 @@ func FuzzMatchesRegexpBehavior(f *testing.F) {
 	})
 
 }
+
+func TestMatchCaptures(t *testing.T) {
+	matcher := PatternToMatcher(
+		CaptureNamed("first", Or(Val(0), Val(1))),
+		Val(2),
+		CaptureNamed("rest", Cat(
+			ZeroOrMore(3),
+			// Nested names take precedence.
+			CaptureNamed("last", Val(4)),
+		)),
+	)
+
+	caps, found, err := MatchCaptures(matcher, []codeAndValue{{1, "a"}, {2, "b"}, {3, "c"}, {3, "d"}, {4, "e"}})
+	if err != nil || !found {
+		t.Fatalf("failed to match: %v", err)
+	}
+	if caps.Get("first").Value() != "a" {
+		t.Fatalf("unexpected first capture %v", caps["first"])
+	}
+	if rest := caps.Values("rest"); !slices.Equal(rest, []string{"c", "d"}) {
+		t.Fatalf("unexpected rest capture %v", rest)
+	}
+	if last := caps.Values("last"); !slices.Equal(last, []string{"e"}) {
+		t.Fatalf("unexpected last capture %v", last)
+	}
+	if caps.Get("missing") != nil || caps.Values("missing") != nil {
+		t.Fatal("unexpected capture")
+	}
+
+	caps, found, _ = MatchCaptures(matcher, []codeAndValue{{1, "a"}, {2, "b"}})
+	if found {
+		t.Fatal("unexpected match")
+	}
+	if caps != nil {
+		t.Fatal("expected no captures")
+	}
+
+	// Match ignores names.
+	if found, _ := Match(matcher, []codeAndValue{{0, ""}, {2, ""}, {4, ""}}); !found {
+		t.Fatal("failed to match")
+	}
+}
+
+func TestMatchCapturesConcurrently(t *testing.T) {
+	e, err := Parse("/a:first/b:rest*", testLookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matcher := e.Matcher(nil)
+
+	errs := make(chan error)
+	for i := range 8 {
+		go func() {
+			want := string(rune('a' + i))
+			for range 100 {
+				caps, found, err := MatchCaptures(matcher, []codeAndValue{{1, want}, {2, want}, {2, want}})
+				if err == nil && (!found || caps.Get("first").Value() != want || len(caps["rest"]) != 2) {
+					err = errors.New("unexpected captures")
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for range 8 {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	type testCase struct {
+		pattern        Matcher
+		shouldMatch    [][]int
+		shouldNotMatch [][]int
+	}
+	testCases := []testCase{
+		{
+			pattern:        PatternToMatcher(Repeat(Cat(Val(0), Val(1)), 1, 2)),
+			shouldMatch:    [][]int{{0, 1}, {0, 1, 0, 1}},
+			shouldNotMatch: [][]int{{}, {0}, {0, 1, 0}, {0, 1, 0, 1, 0, 1}},
+		},
+		{
+			pattern:        PatternToMatcher(Repeat(Val(0), 2, 2), Val(1)),
+			shouldMatch:    [][]int{{0, 0, 1}},
+			shouldNotMatch: [][]int{{0, 1}, {0, 0, 0, 1}},
+		},
+		{
+			pattern:        PatternToMatcher(ZeroOrMoreOf(Or(Val(0), Cat(Val(1), Val(2)))), Val(3)),
+			shouldMatch:    [][]int{{3}, {0, 3}, {1, 2, 0, 1, 2, 3}},
+			shouldNotMatch: [][]int{{1, 3}, {0, 2, 3}},
+		},
+		{
+			pattern:        PatternToMatcher(OneOrMoreOf(Cat(Val(0), Optional(Val(1))))),
+			shouldMatch:    [][]int{{0}, {0, 1, 0}, {0, 0, 0, 1}},
+			shouldNotMatch: [][]int{{}, {1}, {0, 1, 1}},
+		},
+		{
+			pattern:        PatternToMatcher(RepeatLazy(Val(0), 1, -1), OptionalLazy(Val(1))),
+			shouldMatch:    [][]int{{0}, {0, 0, 1}},
+			shouldNotMatch: [][]int{{}, {1}, {0, 1, 1}},
+		},
+		{
+			pattern:        PatternToMatcher(NotCode(0, 1), ZeroOrMoreOf(NotCode(2))),
+			shouldMatch:    [][]int{{2}, {3, 0, 1}, {4, 4}},
+			shouldNotMatch: [][]int{{}, {0}, {1, 3}, {3, 2}},
+		},
+	}
+	for i, tc := range testCases {
+		for _, codes := range tc.shouldMatch {
+			if matches, _ := Match(tc.pattern, codesToCodeAndValue(codes)); !matches {
+				t.Errorf("case %d should match %v", i, codes)
+			}
+		}
+		for _, codes := range tc.shouldNotMatch {
+			if matches, _ := Match(tc.pattern, codesToCodeAndValue(codes)); matches {
+				t.Errorf("case %d should not match %v", i, codes)
+			}
+		}
+	}
+}
+
+func TestRepeatPanicsOnInvalidBounds(t *testing.T) {
+	for _, bounds := range [][2]int{{-1, 2}, {3, 2}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected a panic for %v", bounds)
+				}
+			}()
+			Repeat(Val(0), bounds[0], bounds[1])
+		}()
+	}
+}
+
+func TestLazyAndGreedy(t *testing.T) {
+	parts := codesToCodeAndValue([]int{1, 1, 1})
+	for _, tc := range []struct {
+		first    Pattern
+		expected int
+	}{
+		{Repeat(Val(1), 0, -1), 3},
+		{RepeatLazy(Val(1), 0, -1), 0},
+		{Repeat(Val(1), 1, 2), 2},
+		{RepeatLazy(Val(1), 1, 2), 1},
+		{Optional(Val(1)), 1},
+		{OptionalLazy(Val(1)), 0},
+	} {
+		caps, found, _ := MatchCaptures(PatternToMatcher(CaptureNamed("first", tc.first), ZeroOrMore(1)), parts)
+		if !found {
+			t.Fatal("failed to match")
+		}
+		if got := len(caps["first"]); got != tc.expected {
+			t.Errorf("expected %d components in the first capture, got %d", tc.expected, got)
+		}
+	}
+}
+
+func TestAnyPriority(t *testing.T) {
+	matcher := PatternToMatcher(
+		CaptureNamed("any", ZeroOrMore(Any)),
+		CaptureNamed("tail", ZeroOrMore(42)),
+	)
+	parts := codesToCodeAndValue([]int{1, 42, 42})
+
+	caps, found, _ := MatchCaptures(matcher, parts)
+	if !found || len(caps["any"]) != 1 || len(caps["tail"]) != 2 {
+		t.Fatalf("expected exact matches to be preferred, got %v", caps)
+	}
+
+	caps, found, _ = MatchCaptures(matcher.WithAnyPriority(AnyInOrder), parts)
+	if !found || len(caps["any"]) != 3 || len(caps["tail"]) != 0 {
+		t.Fatalf("expected Any to be greedy, got %v", caps)
+	}
+
+	caps, found, _ = MatchCaptures(PatternToMatcher(
+		CaptureNamed("any", RepeatLazy(Val(Any), 0, -1)),
+		CaptureNamed("tail", ZeroOrMore(42)),
+	).WithAnyPriority(AnyInOrder), parts)
+	if !found || len(caps["any"]) != 1 || len(caps["tail"]) != 2 {
+		t.Fatalf("expected a lazy Any, got %v", caps)
+	}
+}
+
+func TestCaptureGroup(t *testing.T) {
+	var before, after Span
+	matcher := PatternToMatcher(
+		CaptureGroupSpan(ZeroOrMore(Any), &before),
+		Val(9),
+		CaptureGroupSpan(ZeroOrMore(Any), &after),
+	)
+	parts := codesToCodeAndValue([]int{1, 2, 9, 3})
+	if found, err := Match(matcher, parts); err != nil || !found {
+		t.Fatalf("failed to match: %v", err)
+	}
+	if before != (Span{0, 2}) || after != (Span{3, 4}) {
+		t.Fatalf("unexpected spans %v %v", before, after)
+	}
+
+	// Slice groups get the components being matched, if they have the type
+	// of the group.
+	var got []codeAndValue
+	var other []int
+	matcher = PatternToMatcher(Val(1), CaptureGroupSlice(CaptureGroupSlice(OneOrMore(2), func(group []codeAndValue) error {
+		got = group
+		return nil
+	}), func(group []int) error {
+		other = group
+		return nil
+	}))
+	parts = []codeAndValue{{1, "a"}, {2, "b"}, {2, "c"}}
+	if found, _ := Match(matcher, parts); !found {
+		t.Fatal("failed to match")
+	}
+	if !slices.Equal(got, parts[1:]) || &got[0] != &parts[1] || cap(got) != 2 || other != nil {
+		t.Fatalf("unexpected group %v %v", got, other)
+	}
+
+	// Nested, repeated and empty groups.
+	var spans []Span
+	record := func(span Span) error {
+		spans = append(spans, span)
+		return nil
+	}
+	matcher = PatternToMatcher(
+		CaptureGroupWithF(ZeroOrMoreOf(CaptureGroupWithF(Cat(Val(1), Optional(Val(2))), record)), record),
+		CaptureGroupWithF(ZeroOrMore(3), record),
+	)
+	if found, _ := Match(matcher, codesToCodeAndValue([]int{1, 1, 2, 1})); !found {
+		t.Fatal("failed to match")
+	}
+	if want := []Span{{0, 1}, {1, 3}, {3, 4}, {0, 4}, {4, 4}}; !slices.Equal(spans, want) {
+		t.Fatalf("expected spans %v, got %v", want, spans)
+	}
+
+	// Only the groups of the matching path are reported.
+	spans = nil
+	matcher = PatternToMatcher(Or(
+		CaptureGroupWithF(Cat(Val(1), Val(2)), record),
+		Cat(Val(1), CaptureGroupWithF(Val(3), record)),
+	))
+	if found, _ := Match(matcher, codesToCodeAndValue([]int{1, 3})); !found {
+		t.Fatal("failed to match")
+	}
+	if want := []Span{{1, 2}}; !slices.Equal(spans, want) {
+		t.Fatalf("expected spans %v, got %v", want, spans)
+	}
+
+	// Spans are relative to the components given to Find.
+	var group Span
+	span, found, _ := Find(PatternToMatcher(Val(1), CaptureGroupSpan(Val(2), &group)), codesToCodeAndValue([]int{0, 0, 1, 2}))
+	if !found || span != (Span{2, 4}) || group != (Span{3, 4}) {
+		t.Fatalf("unexpected spans %v %v", span, group)
+	}
+
+	errBoom := errors.New("boom")
+	matcher = PatternToMatcher(CaptureGroupWithF(Val(1), func(Span) error { return errBoom }))
+	if _, err := Match(matcher, codesToCodeAndValue([]int{1})); !errors.Is(err, errBoom) {
+		t.Fatalf("expected the group error, got %v", err)
+	}
+	if _, err := matcher.WithDFA(); !errors.Is(err, ErrDFAUnsupported) {
+		t.Fatalf("expected ErrDFAUnsupported, got %v", err)
+	}
+}
+
+func TestCaptureGroupKeepsAnyPriority(t *testing.T) {
+	// The group marks in front of Any don't stop it from being tried last.
+	var anys []Span
+	matcher := PatternToMatcher(
+		ZeroOrMoreOf(CaptureGroupWithF(Val(Any), func(span Span) error {
+			anys = append(anys, span)
+			return nil
+		})),
+		ZeroOrMore(42),
+	)
+	if found, _ := Match(matcher, codesToCodeAndValue([]int{1, 42, 42})); !found {
+		t.Fatal("failed to match")
+	}
+	if want := []Span{{0, 1}}; !slices.Equal(anys, want) {
+		t.Fatalf("expected spans %v, got %v", want, anys)
+	}
+}
@@ -0,0 +1,386 @@
+package meg
+
+import (
+	"fmt"
+	"slices"
+)
+
+// The textual pattern syntax mirrors multiaddr strings:
+//
+//	pattern    = { "/" term }
+//	term       = atom [ ":" name ] [ quantifier [ "?" ] ]
+//	atom       = protocol | "*" | "!" protocol | "!{" protocol { "|" protocol } "}" |
+//	             "{" seq { "|" seq } "}"
+//	seq        = [ "/" ] term { "/" term }
+//	quantifier = "?" | "*" | "+" | "{" n "}" | "{" min "," [ max ] "}"
+//
+// A protocol matches a component with that protocol, "*" matches any
+// component, "!" matches a component with any other protocol, and braces group
+// alternatives. A quantifier makes the atom optional, or repeats it zero or
+// more times, one or more times, or the given number of times, as many times
+// as possible. A "?" after the quantifier makes it lazy: the atom is matched as
+// few times as possible.
+//
+// ":name" captures the components matched by the atom under that name, as
+// with CaptureNamed. For example, "/{ip4|ip6}:ip/{tcp|udp}/*/quic-v1?/p2p:peer*".
+
+// maxRepeat bounds the counts of the {min,max} quantifier, as each repetition
+// copies the states of the atom.
+const maxRepeat = 1000
+
+// SyntaxError is returned by Parse for malformed patterns.
+type SyntaxError struct {
+	Pattern string
+	// Offset is the byte offset in Pattern where the error was found.
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at offset %d in %q: %s", e.Offset, e.Pattern, e.Msg)
+}
+
+type nodeKind int
+
+const (
+	nodeCode nodeKind = iota
+	nodeNot
+	nodeCat
+	nodeOr
+)
+
+// node is a parsed pattern. nodeCode nodes match a single component, Any
+// included, and nodeNot nodes match a component without one of codes.
+type node struct {
+	kind     nodeKind
+	code     int
+	codes    []int
+	children []node
+	// capture is the name given with ":name", if any.
+	capture string
+	// min and max are the repetition bounds. max is -1 when unbounded.
+	min, max int
+	lazy     bool
+}
+
+// Expr is a parsed textual pattern. Its named captures are recorded in the
+// Captures returned by MatchCaptures, and can also be bound to capture
+// functions with Pattern or Matcher. An Expr is immutable and safe for
+// concurrent use.
+type Expr struct {
+	src   string
+	root  node
+	names []string
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Names returns the capture names used in the expression, in order of first
+// appearance.
+func (e *Expr) Names() []string {
+	return slices.Clone(e.names)
+}
+
+// Pattern returns the Pattern for the expression. Named captures are bound to
+// the functions in bind. Names without a function are matched but not
+// captured, and functions for names that aren't in the expression are
+// ignored.
+func (e *Expr) Pattern(bind map[string]CaptureFunc) Pattern {
+	return e.root.pattern(bind, nil)
+}
+
+// Matcher is a shorthand for PatternToMatcher(e.Pattern(bind)).
+func (e *Expr) Matcher(bind map[string]CaptureFunc) Matcher {
+	return PatternToMatcher(e.Pattern(bind))
+}
+
+func (n *node) pattern(bind map[string]CaptureFunc, f CaptureFunc) Pattern {
+	if n.capture != "" {
+		inner := *n
+		inner.capture = ""
+		return CaptureNamed(n.capture, inner.pattern(bind, bind[n.capture]))
+	}
+	var p Pattern
+	switch n.kind {
+	case nodeCode:
+		p = CaptureWithF(n.code, f)
+	case nodeNot:
+		p = captureNotWithF(n.codes, f)
+	case nodeCat, nodeOr:
+		ps := make([]Pattern, len(n.children))
+		for i := range n.children {
+			ps[i] = n.children[i].pattern(bind, f)
+		}
+		if n.kind == nodeCat {
+			p = Cat(ps...)
+		} else {
+			p = Or(ps...)
+		}
+	}
+	switch {
+	case n.min == 1 && n.max == 1:
+		return p
+	case n.lazy:
+		return RepeatLazy(p, n.min, n.max)
+	case n.min == 0 && n.max == 1:
+		return Optional(p)
+	}
+	return Repeat(p, n.min, n.max)
+}
+
+// Parse parses a textual pattern. lookup returns the code of a protocol name.
+func Parse(pattern string, lookup func(name string) (code int, ok bool)) (*Expr, error) {
+	p := parser{src: pattern, lookup: lookup}
+	var terms []node
+	for p.pos < len(p.src) {
+		if err := p.expect('/'); err != nil {
+			return nil, err
+		}
+		t, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+	return &Expr{
+		src:   pattern,
+		root:  node{kind: nodeCat, children: terms, min: 1, max: 1},
+		names: p.names,
+	}, nil
+}
+
+type parser struct {
+	src    string
+	pos    int
+	lookup func(string) (int, bool)
+	names  []string
+}
+
+func (p *parser) errorf(offset int, format string, args ...any) error {
+	return &SyntaxError{Pattern: p.src, Offset: offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf(p.pos, "expected %q, got %s", c, p.describe())
+	}
+	p.pos++
+	return nil
+}
+
+// describe describes the next byte for error messages.
+func (p *parser) describe() string {
+	if p.pos >= len(p.src) {
+		return "end of pattern"
+	}
+	return fmt.Sprintf("%q", p.src[p.pos])
+}
+
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.'
+}
+
+func (p *parser) name() string {
+	start := p.pos
+	for p.pos < len(p.src) && isNameByte(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) term() (node, error) {
+	var n node
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		n = node{kind: nodeCode, code: matchAny}
+	case c == '!':
+		p.pos++
+		n = node{kind: nodeNot}
+		group := p.peek() == '{'
+		if group {
+			p.pos++
+		}
+		for {
+			code, err := p.protocol()
+			if err != nil {
+				return node{}, err
+			}
+			n.codes = append(n.codes, code)
+			if !group || p.peek() != '|' {
+				break
+			}
+			p.pos++
+		}
+		if group {
+			if err := p.expect('}'); err != nil {
+				return node{}, err
+			}
+		}
+	case c == '{':
+		p.pos++
+		n = node{kind: nodeOr}
+		for {
+			alt, err := p.seq()
+			if err != nil {
+				return node{}, err
+			}
+			n.children = append(n.children, alt)
+			if p.peek() != '|' {
+				break
+			}
+			p.pos++
+		}
+		if err := p.expect('}'); err != nil {
+			return node{}, err
+		}
+	case isNameByte(c):
+		code, err := p.protocol()
+		if err != nil {
+			return node{}, err
+		}
+		n = node{kind: nodeCode, code: code}
+	default:
+		return node{}, p.errorf(p.pos, "expected a protocol, '*', '!' or '{', got %s", p.describe())
+	}
+	n.min, n.max = 1, 1
+
+	if p.peek() == ':' {
+		p.pos++
+		nameStart := p.pos
+		name := p.name()
+		if name == "" {
+			return node{}, p.errorf(nameStart, "expected a capture name, got %s", p.describe())
+		}
+		if hasCapture(n) {
+			return node{}, p.errorf(nameStart-1, "nested captures are not supported")
+		}
+		n.capture = name
+		if !slices.Contains(p.names, name) {
+			p.names = append(p.names, name)
+		}
+	}
+
+	if err := p.quantifier(&n); err != nil {
+		return node{}, err
+	}
+	return n, nil
+}
+
+// protocol parses a protocol name and returns its code.
+func (p *parser) protocol() (int, error) {
+	start := p.pos
+	name := p.name()
+	if name == "" {
+		return 0, p.errorf(start, "expected a protocol, got %s", p.describe())
+	}
+	code, ok := p.lookup(name)
+	if !ok {
+		return 0, p.errorf(start, "unknown protocol %q", name)
+	}
+	return code, nil
+}
+
+// quantifier parses an optional quantifier and sets the repetition bounds of
+// n.
+func (p *parser) quantifier(n *node) error {
+	switch p.peek() {
+	case '?':
+		n.min, n.max = 0, 1
+	case '*':
+		n.min, n.max = 0, -1
+	case '+':
+		n.min, n.max = 1, -1
+	case '{':
+		start := p.pos
+		p.pos++
+		var err error
+		if n.min, err = p.number(); err != nil {
+			return err
+		}
+		n.max = n.min
+		if p.peek() == ',' {
+			p.pos++
+			n.max = -1
+			if p.peek() != '}' {
+				if n.max, err = p.number(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := p.expect('}'); err != nil {
+			return err
+		}
+		if n.max >= 0 && n.max < n.min {
+			return p.errorf(start, "invalid repetition: max is less than min")
+		}
+		// Undo the increment below.
+		p.pos--
+	default:
+		return nil
+	}
+	p.pos++
+	if p.peek() == '?' {
+		p.pos++
+		n.lazy = true
+	}
+	return nil
+}
+
+// number parses a repetition count.
+func (p *parser) number() (int, error) {
+	start := p.pos
+	v := 0
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		v = v*10 + int(p.src[p.pos]-'0')
+		if v > maxRepeat {
+			return 0, p.errorf(start, "repetition count is larger than %d", maxRepeat)
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, p.errorf(p.pos, "expected a number, got %s", p.describe())
+	}
+	return v, nil
+}
+
+// seq parses the sequence of terms of an alternative in a group.
+func (p *parser) seq() (node, error) {
+	if p.peek() == '/' {
+		p.pos++
+	}
+	n := node{kind: nodeCat, min: 1, max: 1}
+	for {
+		t, err := p.term()
+		if err != nil {
+			return node{}, err
+		}
+		n.children = append(n.children, t)
+		if p.peek() != '/' {
+			return n, nil
+		}
+		p.pos++
+	}
+}
+
+func hasCapture(n node) bool {
+	if n.capture != "" {
+		return true
+	}
+	for _, c := range n.children {
+		if hasCapture(c) {
+			return true
+		}
+	}
+	return false
+}
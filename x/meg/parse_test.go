@@ -0,0 +1,187 @@
+package meg
+
+import (
+	"errors"
+	"testing"
+)
+
+var testCodes = map[string]int{
+	"a":     1,
+	"b":     2,
+	"c":     3,
+	"d":     4,
+	"p2p-x": 5,
+}
+
+func testLookup(name string) (int, bool) {
+	code, ok := testCodes[name]
+	return code, ok
+}
+
+func TestParse(t *testing.T) {
+	type testCase struct {
+		pattern        string
+		shouldMatch    [][]int
+		shouldNotMatch [][]int
+	}
+	testCases := []testCase{
+		{
+			pattern:        "",
+			shouldMatch:    [][]int{{}},
+			shouldNotMatch: [][]int{{1}},
+		},
+		{
+			pattern:        "/a/b",
+			shouldMatch:    [][]int{{1, 2}},
+			shouldNotMatch: [][]int{{1}, {2, 1}, {1, 2, 3}},
+		},
+		{
+			pattern:        "/{a|b}/{c|d}",
+			shouldMatch:    [][]int{{1, 3}, {1, 4}, {2, 3}, {2, 4}},
+			shouldNotMatch: [][]int{{1}, {3, 1}, {1, 2}},
+		},
+		{
+			pattern:        "/a/*/b?/c*",
+			shouldMatch:    [][]int{{1, 9}, {1, 9, 2}, {1, 9, 3, 3}, {1, 9, 2, 3}},
+			shouldNotMatch: [][]int{{1}, {1, 9, 2, 2}, {1, 9, 3, 2}},
+		},
+		{
+			pattern:        "/a+/p2p-x",
+			shouldMatch:    [][]int{{1, 5}, {1, 1, 1, 5}},
+			shouldNotMatch: [][]int{{5}, {1}},
+		},
+		{
+			pattern:        "/a/{b/c|/d}?",
+			shouldMatch:    [][]int{{1}, {1, 2, 3}, {1, 4}},
+			shouldNotMatch: [][]int{{1, 2}, {1, 3}, {1, 4, 4}},
+		},
+		{
+			pattern:        "/{a/b}*/c",
+			shouldMatch:    [][]int{{3}, {1, 2, 3}, {1, 2, 1, 2, 3}},
+			shouldNotMatch: [][]int{{1, 3}, {1, 2, 1, 3}},
+		},
+		{
+			pattern:        "/{a|b/c}+",
+			shouldMatch:    [][]int{{1}, {2, 3}, {1, 2, 3, 1}},
+			shouldNotMatch: [][]int{{}, {2}, {1, 2}},
+		},
+		{
+			pattern:        "/a{2}/b{1,2}/c{2,}/d{0,1}",
+			shouldMatch:    [][]int{{1, 1, 2, 3, 3}, {1, 1, 2, 2, 3, 3, 3, 4}},
+			shouldNotMatch: [][]int{{1, 2, 3, 3}, {1, 1, 2, 2, 2, 3, 3}, {1, 1, 2, 3}, {1, 1, 2, 3, 3, 4, 4}},
+		},
+		{
+			pattern:        "/!a/!{b|c}*?/d??",
+			shouldMatch:    [][]int{{2}, {9, 1, 4}, {4, 4}, {5}},
+			shouldNotMatch: [][]int{{1}, {2, 2}, {2, 3}},
+		},
+		{
+			pattern:        "/**",
+			shouldMatch:    [][]int{{}, {1}, {1, 2, 3}},
+			shouldNotMatch: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		e, err := Parse(tc.pattern, testLookup)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", tc.pattern, err)
+		}
+		if e.String() != tc.pattern {
+			t.Fatalf("unexpected source %q", e.String())
+		}
+		m := e.Matcher(nil)
+		for _, codes := range tc.shouldMatch {
+			if matches, _ := Match(m, codesToCodeAndValue(codes)); !matches {
+				t.Errorf("%q should match %v", tc.pattern, codes)
+			}
+		}
+		for _, codes := range tc.shouldNotMatch {
+			if matches, _ := Match(m, codesToCodeAndValue(codes)); matches {
+				t.Errorf("%q should not match %v", tc.pattern, codes)
+			}
+		}
+	}
+}
+
+func TestParseCaptures(t *testing.T) {
+	e, err := Parse("/{a|b}:first/c/d:rest*", testLookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := e.Names(); len(names) != 2 || names[0] != "first" || names[1] != "rest" {
+		t.Fatalf("unexpected names %v", names)
+	}
+
+	// The same Expr can be bound to different functions.
+	for _, want := range []string{"x", "y"} {
+		var first string
+		var rest []string
+		m := e.Matcher(map[string]CaptureFunc{
+			"first": func(s Matchable) error {
+				first = s.Value()
+				return nil
+			},
+			"rest": func(s Matchable) error {
+				rest = append(rest, s.Value())
+				return nil
+			},
+		})
+		found, err := Match(m, []codeAndValue{{2, want}, {3, ""}, {4, "1"}, {4, "2"}})
+		if err != nil || !found {
+			t.Fatalf("failed to match: %v", err)
+		}
+		if first != want {
+			t.Fatalf("expected %q, got %q", want, first)
+		}
+		if len(rest) != 2 || rest[0] != "1" || rest[1] != "2" {
+			t.Fatalf("unexpected rest %v", rest)
+		}
+	}
+
+	// Unbound names still match.
+	found, err := Match(e.Matcher(nil), []codeAndValue{{1, ""}, {3, ""}})
+	if err != nil || !found {
+		t.Fatalf("failed to match: %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		offset  int
+	}{
+		{"a", 0},
+		{"/", 1},
+		{"/a/", 3},
+		{"/a//b", 3},
+		{"/e", 1},
+		{"/a/p2p", 3},
+		{"/{a|b", 5},
+		{"/{a|}", 4},
+		{"/a:", 3},
+		{"/{a:x|b}:y", 8},
+		{"/a???", 4},
+		{"/a)", 2},
+		{"/a{", 3},
+		{"/a{x}", 3},
+		{"/a{1", 4},
+		{"/a{1,x}", 5},
+		{"/a{3,2}", 2},
+		{"/a{1001}", 3},
+		{"/!", 2},
+		{"/!*", 2},
+		{"/!{a|*}", 5},
+		{"/!{a", 4},
+	}
+	for _, tc := range testCases {
+		_, err := Parse(tc.pattern, testLookup)
+		var synErr *SyntaxError
+		if !errors.As(err, &synErr) {
+			t.Fatalf("%q: expected a SyntaxError, got %v", tc.pattern, err)
+		}
+		if synErr.Offset != tc.offset {
+			t.Errorf("%q: expected offset %d, got %d (%v)", tc.pattern, tc.offset, synErr.Offset, err)
+		}
+	}
+}
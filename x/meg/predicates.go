@@ -0,0 +1,76 @@
+package meg
+
+import (
+	"fmt"
+	"net/netip"
+	"path"
+	"strings"
+)
+
+// PortInRange accepts components whose value is a big-endian 16-bit port, such
+// as /tcp and /udp, between lo and hi inclusive.
+func PortInRange(lo, hi uint16) Predicate {
+	return func(s Matchable) bool {
+		v := s.RawValue()
+		if len(v) != 2 {
+			return false
+		}
+		port := uint16(v[0])<<8 | uint16(v[1])
+		return port >= lo && port <= hi
+	}
+}
+
+// InPrefix accepts /ip4 and /ip6 components whose address is in prefix.
+func InPrefix(prefix netip.Prefix) Predicate {
+	return func(s Matchable) bool {
+		addr, ok := netip.AddrFromSlice(s.RawValue())
+		return ok && prefix.Contains(addr)
+	}
+}
+
+// ValueEquals accepts components whose string value is v.
+func ValueEquals(v string) Predicate {
+	return func(s Matchable) bool {
+		return s.Value() == v
+	}
+}
+
+// ValueGlob accepts components whose string value matches pattern, using the
+// syntax of path.Match. It returns path.ErrBadPattern if pattern is malformed.
+func ValueGlob(pattern string) (Predicate, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	return func(s Matchable) bool {
+		ok, _ := path.Match(pattern, s.Value())
+		return ok
+	}, nil
+}
+
+// MustValueGlob is like ValueGlob, but panics if pattern is malformed. It is
+// meant for patterns that are known to be valid, such as constants.
+func MustValueGlob(pattern string) Predicate {
+	p, err := ValueGlob(pattern)
+	if err != nil {
+		panic("meg: " + err.Error())
+	}
+	return p
+}
+
+// DNSSuffix accepts components whose value is the domain name suffix or a
+// subdomain of it, ignoring case and a trailing dot. A suffix starting with a
+// dot only accepts subdomains.
+func DNSSuffix(suffix string) Predicate {
+	subdomainsOnly := strings.HasPrefix(suffix, ".")
+	suffix = strings.TrimSuffix(strings.TrimPrefix(suffix, "."), ".")
+	return func(s Matchable) bool {
+		name := strings.TrimSuffix(s.Value(), ".")
+		if len(name) < len(suffix) || !strings.EqualFold(name[len(name)-len(suffix):], suffix) {
+			return false
+		}
+		if len(name) == len(suffix) {
+			return !subdomainsOnly
+		}
+		return name[len(name)-len(suffix)-1] == '.'
+	}
+}
@@ -0,0 +1,98 @@
+package meg
+
+import (
+	"errors"
+	"net/netip"
+	"path"
+	"testing"
+)
+
+func TestValWhere(t *testing.T) {
+	isLower := func(s Matchable) bool { return s.Value() == "a" || s.Value() == "b" }
+	m := PatternToMatcher(
+		Or(ValWhere(1, isLower), Cat(Val(1), Val(2))),
+	)
+	for _, tc := range []struct {
+		parts []codeAndValue
+		match bool
+	}{
+		{[]codeAndValue{{1, "a"}}, true},
+		{[]codeAndValue{{1, "b"}}, true},
+		{[]codeAndValue{{1, "c"}}, false},
+		{[]codeAndValue{{2, "a"}}, false},
+		// A rejected component only fails its own path.
+		{[]codeAndValue{{1, "c"}, {2, ""}}, true},
+	} {
+		if found, _ := Match(m, tc.parts); found != tc.match {
+			t.Errorf("%v: expected %v, got %v", tc.parts, tc.match, found)
+		}
+	}
+
+	var captured []string
+	m = PatternToMatcher(
+		CaptureWhereWithF(Any, ValueEquals("x"), func(s Matchable) error {
+			captured = append(captured, s.Value())
+			return nil
+		}),
+		ZeroOrMore(Any),
+	)
+	if found, _ := Match(m, []codeAndValue{{1, "y"}}); found {
+		t.Fatal("unexpected match")
+	}
+	if found, _ := Match(m, []codeAndValue{{3, "x"}, {1, "y"}}); !found {
+		t.Fatal("failed to match")
+	}
+	if len(captured) != 1 || captured[0] != "x" {
+		t.Fatalf("unexpected captures %v", captured)
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	port := func(p uint16) Matchable { return &codeAndValue{val: string([]byte{byte(p >> 8), byte(p)})} }
+	addr := func(s string) Matchable { return &codeAndValue{val: string(netip.MustParseAddr(s).AsSlice())} }
+	str := func(s string) Matchable { return &codeAndValue{val: s} }
+
+	for _, tc := range []struct {
+		name string
+		pred Predicate
+		v    Matchable
+		want bool
+	}{
+		{"port in range", PortInRange(4000, 5000), port(4000), true},
+		{"port at end of range", PortInRange(4000, 5000), port(5000), true},
+		{"port below range", PortInRange(4000, 5000), port(3999), false},
+		{"port above range", PortInRange(4000, 5000), port(5001), false},
+		{"port wrong size", PortInRange(0, 65535), str("a"), false},
+		{"ip4 in prefix", InPrefix(netip.MustParsePrefix("10.0.0.0/8")), addr("10.1.2.3"), true},
+		{"ip4 out of prefix", InPrefix(netip.MustParsePrefix("10.0.0.0/8")), addr("11.1.2.3"), false},
+		{"ip6 in prefix", InPrefix(netip.MustParsePrefix("fd00::/8")), addr("fd12::1"), true},
+		{"ip6 in ip4 prefix", InPrefix(netip.MustParsePrefix("10.0.0.0/8")), addr("fd12::1"), false},
+		{"not an address", InPrefix(netip.MustParsePrefix("10.0.0.0/8")), str("abc"), false},
+		{"equal", ValueEquals("abc"), str("abc"), true},
+		{"not equal", ValueEquals("abc"), str("abcd"), false},
+		{"glob", MustValueGlob("*.example.com"), str("a.example.com"), true},
+		{"glob mismatch", MustValueGlob("*.example.com"), str("example.com"), false},
+		{"dns suffix", DNSSuffix("example.com"), str("example.com"), true},
+		{"dns subdomain", DNSSuffix("example.com"), str("a.b.Example.COM."), true},
+		{"dns other domain", DNSSuffix("example.com"), str("badexample.com"), false},
+		{"dns short", DNSSuffix("example.com"), str("com"), false},
+		{"dns subdomains only", DNSSuffix(".example.com"), str("example.com"), false},
+		{"dns subdomains only match", DNSSuffix(".example.com."), str("a.example.com"), true},
+	} {
+		if got := tc.pred(tc.v); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestValueGlobBadPattern(t *testing.T) {
+	if _, err := ValueGlob("["); !errors.Is(err, path.ErrBadPattern) {
+		t.Fatalf("expected ErrBadPattern, got %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	MustValueGlob("[")
+}
@@ -0,0 +1,211 @@
+package meg
+
+// Span is the range of components [Start, End) matched by a pattern.
+type Span struct {
+	Start, End int
+}
+
+// Len returns the number of components in the span.
+func (s Span) Len() int {
+	return s.End - s.Start
+}
+
+// Anchor restricts where a match found by Search can start and end.
+type Anchor uint8
+
+const (
+	// AnchorNone lets matches start and end anywhere.
+	AnchorNone Anchor = 0
+	// AnchorStart requires matches to start at the first component.
+	AnchorStart Anchor = 1 << iota
+	// AnchorEnd requires matches to end at the last component.
+	AnchorEnd
+	// AnchorBoth requires matches to span all components, like Match.
+	AnchorBoth = AnchorStart | AnchorEnd
+)
+
+// Search returns the leftmost-longest match of matcher in components allowed
+// by anchor, and its named captures. Capture funcs are only called for the
+// returned match.
+//
+// The longest match wins even if the pattern prefers a shorter one: lazy
+// quantifiers, such as those of RepeatLazy, and AnyPriority only choose
+// between the paths that match the same span, and so decide the captures.
+// This applies to MatchPrefix, MatchSuffix, Find and FindAll too.
+func Search[T any, PT interface {
+	*T
+	Matchable
+}](matcher Matcher, components []T, anchor Anchor) (Span, Captures, bool, error) {
+	return search[T, PT](matcher, components, anchor, true)
+}
+
+// MatchPrefix returns the number of components of the longest prefix of
+// components that matches matcher.
+func MatchPrefix[T any, PT interface {
+	*T
+	Matchable
+}](matcher Matcher, components []T) (int, bool, error) {
+	span, _, ok, err := search[T, PT](matcher, components, AnchorStart, false)
+	return span.End, ok, err
+}
+
+// MatchSuffix returns the index of the first component of the longest suffix
+// of components that matches matcher.
+func MatchSuffix[T any, PT interface {
+	*T
+	Matchable
+}](matcher Matcher, components []T) (int, bool, error) {
+	span, _, ok, err := search[T, PT](matcher, components, AnchorEnd, false)
+	return span.Start, ok, err
+}
+
+// Find returns the leftmost-longest match of matcher in components. As with
+// Search, lazy quantifiers don't make the match shorter.
+func Find[T any, PT interface {
+	*T
+	Matchable
+}](matcher Matcher, components []T) (Span, bool, error) {
+	span, _, ok, err := search[T, PT](matcher, components, AnchorNone, false)
+	return span, ok, err
+}
+
+// FindAll returns the successive non-overlapping matches of matcher in
+// components. As with regexp, an empty match right after a previous match is
+// ignored. Capture funcs are called for every match, in order.
+//
+// FindAll reads the components once: the search for the next match starts as
+// soon as a match is found, alongside the threads that may still extend it.
+func FindAll[T any, PT interface {
+	*T
+	Matchable
+}](matcher Matcher, components []T) ([]Span, error) {
+	states := matcher.states
+	preferExact := matcher.anyPriority == PreferExact
+	visitedBitSet := make([]uint64, (len(states)+63)/64)
+
+	currentStates := statesAndCaptures{starts: make([]int, 0, 16)}
+	nextStates := statesAndCaptures{starts: make([]int, 0, 16)}
+	currentStates = appendState(currentStates, states, matcher.startIdx, nil, 0, 0, visitedBitSet, preferExact)
+
+	var spans []Span
+	levels := []findLevel{{from: 0, ignore: -1}}
+	// emit reports the matches of the first levels, once they can't be
+	// extended, or all the matches found at the end.
+	emit := func(end bool) error {
+		for len(levels) > 1 && levels[0].found {
+			if !end && len(currentStates.starts) > 0 && currentStates.starts[0] < levels[1].from {
+				break
+			}
+			if _, err := runCaptures(levels[0].cap, false, components); err != nil {
+				return err
+			}
+			spans = append(spans, levels[0].best)
+			levels = levels[1:]
+		}
+		return nil
+	}
+
+	for ic := 0; ; ic++ {
+		// Threads are sorted by start, and belong to the last level that
+		// started at or before their start. Only one thread can be in the
+		// done state.
+		li := 0
+		for i, stateIndex := range currentStates.states {
+			start := currentStates.starts[i]
+			for li+1 < len(levels) && start >= levels[li+1].from {
+				li++
+			}
+			if states[stateIndex].codeOrKind != done {
+				continue
+			}
+			lv := &levels[li]
+			if start == ic && ic == lv.ignore {
+				break
+			}
+			// The thread improves the match of its level, as threads that
+			// start after the match are dropped. The levels after it searched
+			// components that are now part of the match, so they start over.
+			lv.found = true
+			lv.best = Span{Start: start, End: ic}
+			lv.cap = currentStates.captures[i]
+			levels = append(levels[:li+1], lv.nextLevel())
+			currentStates = currentStates.keepStartedBy(start)
+			if levels[li+1].from == ic {
+				clear(visitedBitSet)
+				for _, idx := range currentStates.states {
+					visitedBitSet[idx/64] |= 1 << (idx % 64)
+				}
+				currentStates = appendState(currentStates, states, matcher.startIdx, nil, ic, ic, visitedBitSet, preferExact)
+			}
+			break
+		}
+		if ic == len(components) {
+			break
+		}
+
+		clear(visitedBitSet)
+		cPtr := PT(&components[ic])
+		for i, stateIndex := range currentStates.states {
+			s := &states[stateIndex]
+			if (s.codeOrKind == matchAny || (s.codeOrKind >= 0 && s.codeOrKind == cPtr.Code())) &&
+				(s.pred == nil || s.pred(cPtr)) {
+				cm := s.pushCapture(currentStates.captures[i], cPtr, false)
+				nextStates = appendState(nextStates, states, s.next, cm, currentStates.starts[i], ic+1, visitedBitSet, preferExact)
+			}
+		}
+		if last := &levels[len(levels)-1]; ic+1 >= last.from {
+			// Start a lower priority thread at the next position.
+			nextStates = appendState(nextStates, states, matcher.startIdx, nil, ic+1, ic+1, visitedBitSet, preferExact)
+		}
+		currentStates, nextStates = nextStates, currentStates
+		nextStates.states = nextStates.states[:0]
+		nextStates.captures = nextStates.captures[:0]
+		nextStates.starts = nextStates.starts[:0]
+
+		if err := emit(false); err != nil {
+			return nil, err
+		}
+	}
+	if err := emit(true); err != nil {
+		return nil, err
+	}
+	return spans, nil
+}
+
+// findLevel is the search for one match of FindAll. The last level hasn't
+// found a match yet, and the levels before it have.
+type findLevel struct {
+	// from is the position of the first thread of the level.
+	from int
+	// ignore is the end of the previous match, where an empty match is
+	// ignored, or -1.
+	ignore int
+	found  bool
+	best   Span
+	cap    *capture
+}
+
+// nextLevel returns the level searching for the match after l's.
+func (l *findLevel) nextLevel() findLevel {
+	if l.best.Len() == 0 {
+		return findLevel{from: l.best.End + 1, ignore: -1}
+	}
+	return findLevel{from: l.best.End, ignore: l.best.End}
+}
+
+// keepStartedBy drops the threads that started after pos.
+func (s statesAndCaptures) keepStartedBy(pos int) statesAndCaptures {
+	n := 0
+	for i, start := range s.starts {
+		if start <= pos {
+			s.states[n] = s.states[i]
+			s.captures[n] = s.captures[i]
+			s.starts[n] = start
+			n++
+		}
+	}
+	s.states = s.states[:n]
+	s.captures = s.captures[:n]
+	s.starts = s.starts[:n]
+	return s
+}
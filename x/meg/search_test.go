@@ -0,0 +1,191 @@
+package meg
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	// /b+/c?
+	matcher := PatternToMatcher(OneOrMore(2), Optional(Val(3)))
+	parts := codesToCodeAndValue([]int{1, 2, 2, 3, 1, 2, 4})
+
+	for _, tc := range []struct {
+		anchor Anchor
+		span   Span
+		ok     bool
+	}{
+		{AnchorNone, Span{1, 4}, true},
+		{AnchorStart, Span{}, false},
+		{AnchorEnd, Span{}, false},
+		{AnchorBoth, Span{}, false},
+	} {
+		span, _, ok, err := Search(matcher, parts, tc.anchor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tc.ok || span != tc.span {
+			t.Errorf("anchor %d: expected %v %v, got %v %v", tc.anchor, tc.span, tc.ok, span, ok)
+		}
+	}
+
+	n, ok, _ := MatchPrefix(matcher, parts[1:])
+	if !ok || n != 3 {
+		t.Fatalf("expected a prefix of 3, got %d %v", n, ok)
+	}
+	start, ok, _ := MatchSuffix(matcher, parts[:6])
+	if !ok || start != 5 {
+		t.Fatalf("expected a suffix at 5, got %d %v", start, ok)
+	}
+	spans, err := FindAll(matcher, parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(spans, []Span{{1, 4}, {5, 6}}) {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+}
+
+func TestSearchCaptures(t *testing.T) {
+	var captured []string
+	matcher := PatternToMatcher(
+		CaptureNamed("x", Val(1)),
+		CaptureZeroOrMoreStrings(2, &captured),
+	)
+	parts := []codeAndValue{{1, "a"}, {1, "b"}, {2, "c"}, {2, "d"}, {1, "e"}, {2, "f"}}
+
+	span, caps, ok, err := Search(matcher, parts, AnchorNone)
+	if err != nil || !ok {
+		t.Fatalf("failed to match: %v", err)
+	}
+	if span != (Span{0, 1}) {
+		t.Fatalf("unexpected span %v", span)
+	}
+	if caps.Get("x").Value() != "a" || len(captured) != 0 {
+		t.Fatalf("unexpected captures %v %v", caps, captured)
+	}
+
+	// Only the captures of the reported matches are run.
+	spans, err := FindAll(matcher, parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(spans, []Span{{0, 1}, {1, 4}, {4, 6}}) {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+	if !slices.Equal(captured, []string{"c", "d", "f"}) {
+		t.Fatalf("unexpected captures %v", captured)
+	}
+}
+
+func TestFindAllReadsComponentsOnce(t *testing.T) {
+	// /a|a*b over a run of a's: each a is a match, and the threads of a*b run
+	// to the end without matching.
+	reads := 0
+	counted := func(Matchable) bool { reads++; return true }
+	matcher := PatternToMatcher(Or(Val(1), Cat(Val(1), Repeat(ValWhere(1, counted), 0, -1), Val(2))))
+	codes := make([]int, 1000)
+	for i := range codes {
+		codes[i] = 1
+	}
+	spans, err := FindAll(matcher, codesToCodeAndValue(codes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != len(codes) {
+		t.Fatalf("expected %d matches, got %d", len(codes), len(spans))
+	}
+	if reads > 2*len(codes) {
+		t.Fatalf("expected at most %d reads, got %d", 2*len(codes), reads)
+	}
+}
+
+// TestSearchMatchesRegexpBehavior compares searches with the leftmost-longest
+// mode of the regexp package.
+func TestSearchMatchesRegexpBehavior(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 2000 {
+		var exp []byte
+		var pattern []Pattern
+		for range 1 + rng.Intn(4) {
+			c := byte('a' + rng.Intn(3))
+			switch rng.Intn(6) {
+			case 4:
+				d := byte('a' + rng.Intn(3))
+				min, max := rng.Intn(3), rng.Intn(4)-1
+				if max >= 0 {
+					max += min
+					exp = fmt.Appendf(exp, "(?:%c%c){%d,%d}", c, d, min, max)
+				} else {
+					exp = fmt.Appendf(exp, "(?:%c%c){%d,}", c, d, min)
+				}
+				p := Cat(Val(int(c)), Val(int(d)))
+				if rng.Intn(2) == 0 {
+					pattern = append(pattern, Repeat(p, min, max))
+				} else {
+					pattern = append(pattern, RepeatLazy(p, min, max))
+				}
+			case 5:
+				exp = append(exp, "[^"...)
+				exp = append(exp, c, ']')
+				pattern = append(pattern, NotCode(int(c)))
+			case 0:
+				exp = append(exp, c, '?')
+				pattern = append(pattern, Optional(Val(int(c))))
+			case 1:
+				exp = append(exp, c, '*')
+				pattern = append(pattern, ZeroOrMore(int(c)))
+			case 2:
+				exp = append(exp, c, '+')
+				pattern = append(pattern, OneOrMore(int(c)))
+			default:
+				exp = append(exp, c)
+				pattern = append(pattern, Val(int(c)))
+			}
+		}
+		corpus := make([]byte, rng.Intn(10))
+		for i := range corpus {
+			corpus[i] = byte('a' + rng.Intn(3))
+		}
+		matcher := PatternToMatcher(pattern...)
+		parts := bytesToCodeAndValue(corpus)
+
+		re := regexp.MustCompile(string(exp))
+		re.Longest()
+		want := re.FindIndex(corpus)
+		span, ok, _ := Find(matcher, parts)
+		if ok != (want != nil) || (ok && (span.Start != want[0] || span.End != want[1])) {
+			t.Fatalf("Find(%s, %s): expected %v, got %v %v", exp, corpus, want, span, ok)
+		}
+
+		wantAll := re.FindAllIndex(corpus, -1)
+		spans, _ := FindAll(matcher, parts)
+		if len(spans) != len(wantAll) {
+			t.Fatalf("FindAll(%s, %s): expected %v, got %v", exp, corpus, wantAll, spans)
+		}
+		for i := range spans {
+			if spans[i].Start != wantAll[i][0] || spans[i].End != wantAll[i][1] {
+				t.Fatalf("FindAll(%s, %s): expected %v, got %v", exp, corpus, wantAll, spans)
+			}
+		}
+
+		prefixRe := regexp.MustCompile("^(?:" + string(exp) + ")")
+		prefixRe.Longest()
+		want = prefixRe.FindIndex(corpus)
+		n, ok, _ := MatchPrefix(matcher, parts)
+		if ok != (want != nil) || (ok && n != want[1]) {
+			t.Fatalf("MatchPrefix(%s, %s): expected %v, got %v %v", exp, corpus, want, n, ok)
+		}
+
+		suffixRe := regexp.MustCompile("(?:" + string(exp) + ")$")
+		suffixRe.Longest()
+		want = suffixRe.FindIndex(corpus)
+		start, ok, _ := MatchSuffix(matcher, parts)
+		if ok != (want != nil) || (ok && start != want[0]) {
+			t.Fatalf("MatchSuffix(%s, %s): expected %v, got %v %v", exp, corpus, want, start, ok)
+		}
+	}
+}
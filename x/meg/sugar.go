@@ -3,6 +3,7 @@ package meg
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -14,8 +15,33 @@ type Pattern = func(states []MatchState, nextIdx int) ([]MatchState, int)
 
 // Matcher holds a graph of match state nodes. Use PatternToMatcher to create.
 type Matcher struct {
-	states   []MatchState
-	startIdx int
+	states      []MatchState
+	startIdx    int
+	anyPriority AnyPriority
+	// dfa is set by WithDFA.
+	dfa *dfa
+}
+
+// AnyPriority controls the order in which the branches of a split are tried
+// when the preferred branch starts with Any. The order decides which path's
+// captures are reported when several paths match.
+type AnyPriority int
+
+const (
+	// PreferExact tries the other branch first, so that components are
+	// matched by exact codes rather than by Any where possible. This makes
+	// Optional(Val(Any)) and ZeroOrMore(Any) lazy. It is the default.
+	PreferExact AnyPriority = iota
+	// AnyInOrder treats Any like any other code: the preferred branch is
+	// tried first, so repetitions of Any are greedy unless a lazy operator
+	// such as RepeatLazy is used.
+	AnyInOrder
+)
+
+// WithAnyPriority returns a copy of the matcher that uses p.
+func (s Matcher) WithAnyPriority(p AnyPriority) Matcher {
+	s.anyPriority = p
+	return s
 }
 
 func (s Matcher) String() string {
@@ -159,6 +185,27 @@ func Val(code int) Pattern {
 	return CaptureString(code, nil)
 }
 
+// ValWhere matches a component with the given code whose value is accepted by
+// pred. A rejected component fails this path of the match, like a component
+// with a different code would.
+func ValWhere(code int, pred Predicate) Pattern {
+	return CaptureWhereWithF(code, pred, nil)
+}
+
+// CaptureWhereWithF is like ValWhere, and calls f with the matched component.
+func CaptureWhereWithF(code int, pred Predicate, f CaptureFunc) Pattern {
+	return func(states []MatchState, nextIdx int) ([]MatchState, int) {
+		newState := MatchState{
+			capture:    f,
+			pred:       pred,
+			codeOrKind: code,
+			next:       nextIdx,
+		}
+		states = append(states, newState)
+		return states, len(states) - 1
+	}
+}
+
 // Any is a special code that matches any value.
 var Any int = matchAny
 
@@ -231,6 +278,171 @@ func CaptureOneOrMoreBytes(code int, vals *[][]byte) Pattern {
 	}
 }
 
+// NotCode matches a single component whose code is not one of codes. Like Any,
+// it is tried last when PreferExact is used.
+func NotCode(codes ...int) Pattern {
+	return captureNotWithF(slices.Clone(codes), nil)
+}
+
+// captureNotWithF matches a single component whose code is not one of codes,
+// and calls f with it. Without codes, it matches any component.
+func captureNotWithF(codes []int, f CaptureFunc) Pattern {
+	if len(codes) == 0 {
+		return CaptureWithF(matchAny, f)
+	}
+	return func(states []MatchState, nextIdx int) ([]MatchState, int) {
+		states = append(states, MatchState{
+			capture: f,
+			pred: func(s Matchable) bool {
+				return !slices.Contains(codes, s.Code())
+			},
+			notCodes:   codes,
+			codeOrKind: matchAny,
+			next:       nextIdx,
+		})
+		return states, len(states) - 1
+	}
+}
+
+// ZeroOrMoreOf matches p repeated zero or more times, as many times as
+// possible. Unlike ZeroOrMore, p can be any pattern.
+func ZeroOrMoreOf(p Pattern) Pattern {
+	return Repeat(p, 0, -1)
+}
+
+// OneOrMoreOf matches p repeated one or more times, as many times as
+// possible.
+func OneOrMoreOf(p Pattern) Pattern {
+	return Repeat(p, 1, -1)
+}
+
+// OptionalLazy is like Optional, but prefers to skip p.
+func OptionalLazy(p Pattern) Pattern {
+	return RepeatLazy(p, 0, 1)
+}
+
+// Repeat matches p repeated at least min and at most max times, as many times
+// as possible. A negative max means there is no upper bound.
+//
+// Repeat panics if min is negative or larger than a non-negative max, like
+// MustValueGlob does for a malformed glob. Check bounds that come from input
+// first, or put them in a pattern for Parse, which returns an error instead.
+//
+// Each repetition up to max copies the states of p, so prefer an unbounded
+// max over a large one.
+func Repeat(p Pattern, min, max int) Pattern {
+	return repeat(p, min, max, false)
+}
+
+// RepeatLazy is like Repeat, but matches p as few times as possible. It panics
+// on invalid bounds, as Repeat does.
+func RepeatLazy(p Pattern, min, max int) Pattern {
+	return repeat(p, min, max, true)
+}
+
+// split returns a split state. The preferred branch is tried first, and is
+// body unless lazy is set.
+func split(body, skip int, lazy bool) MatchState {
+	if lazy {
+		body, skip = skip, body
+	}
+	return MatchState{next: body, codeOrKind: encodeSplitIdx(skip)}
+}
+
+func repeat(p Pattern, min, max int, lazy bool) Pattern {
+	if min < 0 || (max >= 0 && max < min) {
+		panic(fmt.Sprintf("meg: invalid repetition {%d,%d}", min, max))
+	}
+	return func(states []MatchState, nextIdx int) ([]MatchState, int) {
+		if max < 0 {
+			// A loop: the split either enters p, which comes back to the
+			// split, or exits.
+			states = append(states, MatchState{})
+			splitIdx := len(states) - 1
+			var bodyIdx int
+			states, bodyIdx = p(states, splitIdx)
+			states[splitIdx] = split(bodyIdx, nextIdx, lazy)
+			nextIdx = splitIdx
+		} else {
+			// Nested optionals: (p(p)?)?
+			for range max - min {
+				var bodyIdx int
+				states, bodyIdx = p(states, nextIdx)
+				states = append(states, split(bodyIdx, nextIdx, lazy))
+				nextIdx = len(states) - 1
+			}
+		}
+		for range min {
+			states, nextIdx = p(states, nextIdx)
+		}
+		return states, nextIdx
+	}
+}
+
+// CaptureNamed records the components matched by p under name in the
+// Captures returned by MatchCaptures. Components matched by a nested
+// CaptureNamed are only recorded under the innermost name.
+func CaptureNamed(name string, p Pattern) Pattern {
+	return func(states []MatchState, nextIdx int) ([]MatchState, int) {
+		start := len(states)
+		states, idx := p(states, nextIdx)
+		for i := start; i < len(states); i++ {
+			if states[i].codeOrKind >= matchAny && states[i].name == "" {
+				states[i].name = name
+			}
+		}
+		return states, idx
+	}
+}
+
+// CaptureGroupWithF calls f with the span of the components matched by p,
+// which may be empty. f is called once per match of p, so a repeated group
+// calls it for every repetition, in order. Group funcs are called in the
+// order in which the groups end, along with the capture funcs of the path.
+func CaptureGroupWithF(p Pattern, f GroupFunc) Pattern {
+	if f == nil {
+		return p
+	}
+	return captureGroup(p, func(_ any, span Span) error { return f(span) })
+}
+
+// CaptureGroupSlice calls f with the components matched by p, sliced from the
+// components being matched, so nothing is copied. The slice is capped so that
+// appending to it doesn't modify the components being matched. It is called
+// like the func of CaptureGroupWithF, but only when the components being
+// matched are a []T: a pattern can handle several component types by nesting
+// CaptureGroupSlice.
+func CaptureGroupSlice[T any](p Pattern, f func(group []T) error) Pattern {
+	if f == nil {
+		return p
+	}
+	return captureGroup(p, func(components any, span Span) error {
+		cs, ok := components.([]T)
+		if !ok {
+			return nil
+		}
+		return f(cs[span.Start:span.End:span.End])
+	})
+}
+
+// captureGroup surrounds the states of p with group marks calling f.
+func captureGroup(p Pattern, f groupFunc) Pattern {
+	return func(states []MatchState, nextIdx int) ([]MatchState, int) {
+		states = append(states, MatchState{codeOrKind: groupEnd, group: f, next: nextIdx})
+		states, idx := p(states, len(states)-1)
+		states = append(states, MatchState{codeOrKind: groupStart, group: f, next: idx})
+		return states, len(states) - 1
+	}
+}
+
+// CaptureGroupSpan stores the span of the components matched by p in span.
+func CaptureGroupSpan(p Pattern, span *Span) Pattern {
+	return CaptureGroupWithF(p, func(s Span) error {
+		*span = s
+		return nil
+	})
+}
+
 func Optional(s Pattern) Pattern {
 	return func(states []MatchState, nextIdx int) ([]MatchState, int) {
 		states, patternIdx := s(states, nextIdx)
@@ -0,0 +1,240 @@
+// Package rank orders a peer's multiaddrs for dialing, in the spirit of Happy
+// Eyeballs (RFC 8305). Addresses that are likely to connect quickly are
+// dialed first, and the others are dialed after a delay if no connection has
+// been established yet.
+package rank
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Locality describes where an address points to.
+type Locality int
+
+const (
+	Loopback Locality = iota
+	Private
+	Public
+	// Unresolved addresses use a DNS name, so their locality isn't known
+	// until they are resolved.
+	Unresolved
+	OtherLocality
+)
+
+// Transport is the class of transport used by an address. Lower values are
+// preferred.
+type Transport int
+
+const (
+	QUIC Transport = iota
+	WebTransport
+	WebRTCDirect
+	TCP
+	OtherTransport
+)
+
+// Family is the IP family of an address.
+type Family int
+
+const (
+	IPv6 Family = iota
+	IPv4
+	OtherFamily
+)
+
+// Class is the ranking key of an address. Addresses are sorted by relay, then
+// locality, transport and family.
+type Class struct {
+	Relay     bool
+	Locality  Locality
+	Transport Transport
+	Family    Family
+}
+
+func (c Class) compare(o Class) int {
+	if c.Relay != o.Relay {
+		if c.Relay {
+			return 1
+		}
+		return -1
+	}
+	return cmp.Or(
+		cmp.Compare(c.Locality, o.Locality),
+		cmp.Compare(c.Transport, o.Transport),
+		cmp.Compare(c.Family, o.Family),
+	)
+}
+
+// Classify returns the ranking class of addr. The class of a relay address is
+// based on the address of the relay.
+func Classify(addr ma.Multiaddr) Class {
+	var c Class
+	for i, comp := range addr {
+		if comp.Code() == ma.P_CIRCUIT {
+			c.Relay = true
+			addr = addr[:i]
+			break
+		}
+	}
+
+	c.Locality = OtherLocality
+	c.Family = OtherFamily
+	first := addr
+	if len(first) > 0 && first[0].Code() == ma.P_IP6ZONE {
+		first = first[1:]
+	}
+	if len(first) > 0 {
+		switch first[0].Code() {
+		case ma.P_IP4, ma.P_IP6:
+			switch {
+			case manet.IsIPLoopback(addr):
+				c.Locality = Loopback
+			case manet.IsPrivateAddr(addr):
+				c.Locality = Private
+			case manet.IsPublicAddr(addr):
+				c.Locality = Public
+			}
+		case ma.P_DNS, ma.P_DNS4, ma.P_DNS6, ma.P_DNSADDR:
+			c.Locality = Unresolved
+		}
+		switch first[0].Code() {
+		case ma.P_IP4, ma.P_DNS4:
+			c.Family = IPv4
+		case ma.P_IP6, ma.P_DNS6:
+			c.Family = IPv6
+		}
+	}
+
+	c.Transport = OtherTransport
+	for code := range addr.ProtocolCodes() {
+		switch code {
+		case ma.P_TCP:
+			c.Transport = TCP
+		case ma.P_QUIC_V1:
+			c.Transport = QUIC
+		case ma.P_WEBTRANSPORT:
+			c.Transport = WebTransport
+		case ma.P_WEBRTC_DIRECT:
+			c.Transport = WebRTCDirect
+		}
+	}
+	return c
+}
+
+// Config holds the delays used to schedule dials. Delays only apply when
+// there are preferred addresses of the same locality and relay status, and
+// they add up: a public IPv4 TCP address is dialed after
+// FamilyDelay+PublicTCPDelay when there are public IPv6 TCP addresses and
+// public QUIC addresses.
+type Config struct {
+	// FamilyDelay delays IPv4 addresses when there are IPv6 addresses of the
+	// same transport class.
+	FamilyDelay time.Duration
+	// PublicTCPDelay delays TCP addresses when there are QUIC, WebTransport or
+	// WebRTC addresses, for public, unresolved and other addresses.
+	PublicTCPDelay time.Duration
+	// PrivateTCPDelay is like PublicTCPDelay for loopback and private
+	// addresses.
+	PrivateTCPDelay time.Duration
+	// RelayDelay delays relay addresses when there are direct addresses.
+	RelayDelay time.Duration
+}
+
+// DefaultConfig is used by Rank and Sort.
+var DefaultConfig = Config{
+	FamilyDelay:     250 * time.Millisecond,
+	PublicTCPDelay:  250 * time.Millisecond,
+	PrivateTCPDelay: 30 * time.Millisecond,
+	RelayDelay:      500 * time.Millisecond,
+}
+
+// Group is a set of addresses of the same Class to dial together.
+type Group struct {
+	Class Class
+	Addrs []ma.Multiaddr
+	// Delay is the time to wait, from the start of dialing, before dialing
+	// this group.
+	Delay time.Duration
+}
+
+// Rank groups addrs with DefaultConfig. See Config.Rank.
+func Rank(addrs []ma.Multiaddr) []Group {
+	return DefaultConfig.Rank(addrs)
+}
+
+// Sort returns addrs in the order given by Rank.
+func Sort(addrs []ma.Multiaddr) []ma.Multiaddr {
+	return DefaultConfig.Sort(addrs)
+}
+
+// Rank groups addrs by Class and computes the delay of each group. Groups are
+// ordered by delay, then by class. The order of addresses within a group, and
+// of groups with the same delay and class, is the order of addrs, so the
+// result is stable.
+func (cfg Config) Rank(addrs []ma.Multiaddr) []Group {
+	type ranked struct {
+		addr  ma.Multiaddr
+		class Class
+	}
+	rs := make([]ranked, len(addrs))
+	for i, a := range addrs {
+		rs[i] = ranked{a, Classify(a)}
+	}
+	slices.SortStableFunc(rs, func(a, b ranked) int { return a.class.compare(b.class) })
+
+	var groups []Group
+	for _, r := range rs {
+		if n := len(groups); n > 0 && groups[n-1].Class == r.class {
+			groups[n-1].Addrs = append(groups[n-1].Addrs, r.addr)
+			continue
+		}
+		groups = append(groups, Group{Class: r.class, Addrs: []ma.Multiaddr{r.addr}})
+	}
+
+	for i := range groups {
+		groups[i].Delay = cfg.delay(groups[i].Class, groups)
+	}
+	slices.SortStableFunc(groups, func(a, b Group) int {
+		return cmp.Or(cmp.Compare(a.Delay, b.Delay), a.Class.compare(b.Class))
+	})
+	return groups
+}
+
+// Sort returns addrs in the order given by Rank.
+func (cfg Config) Sort(addrs []ma.Multiaddr) []ma.Multiaddr {
+	out := make([]ma.Multiaddr, 0, len(addrs))
+	for _, g := range cfg.Rank(addrs) {
+		out = append(out, g.Addrs...)
+	}
+	return out
+}
+
+func (cfg Config) delay(c Class, groups []Group) time.Duration {
+	has := func(f func(o Class) bool) bool {
+		return slices.ContainsFunc(groups, func(g Group) bool { return f(g.Class) })
+	}
+
+	var d time.Duration
+	if c.Relay && has(func(o Class) bool { return !o.Relay }) {
+		d += cfg.RelayDelay
+	}
+	sameKind := func(o Class) bool { return o.Relay == c.Relay && o.Locality == c.Locality }
+	if c.Transport == TCP && has(func(o Class) bool { return sameKind(o) && o.Transport < TCP }) {
+		if c.Locality <= Private {
+			d += cfg.PrivateTCPDelay
+		} else {
+			d += cfg.PublicTCPDelay
+		}
+	}
+	if c.Family == IPv4 && has(func(o Class) bool {
+		return sameKind(o) && o.Transport == c.Transport && o.Family == IPv6
+	}) {
+		d += cfg.FamilyDelay
+	}
+	return d
+}
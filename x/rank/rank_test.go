@@ -0,0 +1,121 @@
+package rank
+
+import (
+	"testing"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+)
+
+func addrs(ss ...string) []ma.Multiaddr {
+	out := make([]ma.Multiaddr, len(ss))
+	for i, s := range ss {
+		out[i] = ma.StringCast(s)
+	}
+	return out
+}
+
+func strs(ms []ma.Multiaddr) []string {
+	out := make([]string, len(ms))
+	for i, m := range ms {
+		out[i] = m.String()
+	}
+	return out
+}
+
+const relayed = "/ip4/8.8.8.8/udp/1/quic-v1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit"
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		addr string
+		want Class
+	}{
+		{"/ip4/127.0.0.1/tcp/1", Class{Locality: Loopback, Transport: TCP, Family: IPv4}},
+		{"/ip4/192.168.1.1/udp/1/quic-v1", Class{Locality: Private, Transport: QUIC, Family: IPv4}},
+		{"/ip6/2001:4860::1/udp/1/quic-v1/webtransport", Class{Locality: Public, Transport: WebTransport, Family: IPv6}},
+		{"/ip4/8.8.8.8/udp/1/webrtc-direct", Class{Locality: Public, Transport: WebRTCDirect, Family: IPv4}},
+		{"/dns4/example.com/tcp/443/tls/ws", Class{Locality: Unresolved, Transport: TCP, Family: IPv4}},
+		{"/dns/example.com/tcp/443", Class{Locality: Unresolved, Transport: TCP, Family: OtherFamily}},
+		{"/ip6zone/eth0/ip6/::1/tcp/1", Class{Locality: Loopback, Transport: TCP, Family: IPv6}},
+		{relayed, Class{Relay: true, Locality: Public, Transport: QUIC, Family: IPv4}},
+		{"/unix/a", Class{Locality: OtherLocality, Transport: OtherTransport, Family: OtherFamily}},
+	} {
+		require.Equal(t, tc.want, Classify(ma.StringCast(tc.addr)), tc.addr)
+	}
+}
+
+func TestRank(t *testing.T) {
+	groups := Rank(addrs(
+		relayed,
+		"/ip4/8.8.8.8/tcp/1",
+		"/ip4/8.8.8.8/udp/1/quic-v1",
+		"/ip6/2001:4860::1/udp/1/quic-v1",
+		"/ip6/2001:4860::1/tcp/1",
+		"/ip4/192.168.1.1/tcp/1",
+		"/ip4/192.168.1.1/udp/1/quic-v1",
+		"/ip4/8.8.4.4/udp/1/quic-v1",
+	))
+
+	type group struct {
+		addrs []string
+		delay time.Duration
+	}
+	var got []group
+	for _, g := range groups {
+		got = append(got, group{strs(g.Addrs), g.Delay})
+	}
+	require.Equal(t, []group{
+		{[]string{"/ip4/192.168.1.1/udp/1/quic-v1"}, 0},
+		{[]string{"/ip6/2001:4860::1/udp/1/quic-v1"}, 0},
+		{[]string{"/ip4/192.168.1.1/tcp/1"}, 30 * time.Millisecond},
+		{[]string{"/ip4/8.8.8.8/udp/1/quic-v1", "/ip4/8.8.4.4/udp/1/quic-v1"}, 250 * time.Millisecond},
+		{[]string{"/ip6/2001:4860::1/tcp/1"}, 250 * time.Millisecond},
+		{[]string{"/ip4/8.8.8.8/tcp/1"}, 500 * time.Millisecond},
+		{[]string{relayed}, 500 * time.Millisecond},
+	}, got)
+}
+
+func TestRankWithoutPreferredAddrs(t *testing.T) {
+	groups := Rank(addrs("/ip4/8.8.8.8/tcp/1", relayed))
+	require.Len(t, groups, 2)
+	require.Zero(t, groups[0].Delay)
+	require.Equal(t, 500*time.Millisecond, groups[1].Delay)
+
+	// Nothing to wait for.
+	groups = Rank(addrs("/ip4/8.8.8.8/tcp/1"))
+	require.Len(t, groups, 1)
+	require.Zero(t, groups[0].Delay)
+
+	groups = Rank(addrs(relayed))
+	require.Len(t, groups, 1)
+	require.Zero(t, groups[0].Delay)
+
+	require.Empty(t, Rank(nil))
+}
+
+func TestSortIsStable(t *testing.T) {
+	in := addrs(
+		"/ip4/8.8.8.8/tcp/3",
+		"/ip4/8.8.8.8/tcp/1",
+		"/ip4/8.8.8.8/tcp/2",
+		"/ip4/127.0.0.1/tcp/1",
+	)
+	require.Equal(t, []string{
+		"/ip4/127.0.0.1/tcp/1",
+		"/ip4/8.8.8.8/tcp/3",
+		"/ip4/8.8.8.8/tcp/1",
+		"/ip4/8.8.8.8/tcp/2",
+	}, strs(Sort(in)))
+	require.Equal(t, "/ip4/8.8.8.8/tcp/3", in[0].String(), "input was modified")
+}
+
+func TestCustomConfig(t *testing.T) {
+	cfg := Config{FamilyDelay: time.Second}
+	groups := cfg.Rank(addrs("/ip4/8.8.8.8/tcp/1", "/ip6/2001:4860::1/tcp/1", "/ip6/2001:4860::1/udp/1/quic-v1"))
+	require.Len(t, groups, 3)
+	require.Equal(t, "/ip6/2001:4860::1/udp/1/quic-v1", groups[0].Addrs[0].String())
+	require.Equal(t, "/ip6/2001:4860::1/tcp/1", groups[1].Addrs[0].String())
+	require.Zero(t, groups[1].Delay)
+	require.Equal(t, time.Second, groups[2].Delay)
+}